@@ -0,0 +1,125 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/0xAX/mysql-tools/sql"
+)
+
+// brackets are symbols which are matched while the cursor is on them
+const brackets = "()'\"`"
+
+// bracketTimeout is time for which the matching bracket is highlighted
+const bracketTimeout = 500 * time.Millisecond
+
+// pairs are closing symbols which are inserted after the opening ones
+// if --auto-pairs is given
+var pairs = map[byte]byte{'(': ')', '\'': '\'', '"': '"', '`': '`'}
+
+// matchingBracket returns offset of the bracket or quote which matches
+// the one under the cursor, or the one before the cursor if there is
+// no such symbol under it. It returns -1 if there is no match in the
+// edited lines.
+func matchingBracket(t *Terminal, cmd *SqlCommandBuffer) int {
+	if t.splitter == nil {
+		return -1
+	}
+	text := cmd.String()
+	for _, position := range []uint64{cmd.Position, cmd.Position - 1} {
+		if position < cmd.LineStart || position >= cmd.Length() {
+			continue
+		}
+		if strings.IndexByte(brackets, text[position]) < 0 {
+			continue
+		}
+		match := sql.MatchingBracket(text, int(position), t.splitter.Delimiter)
+		if match < int(cmd.LineStart) {
+			return -1
+		}
+		return match
+	}
+	return -1
+}
+
+// bracketStyle returns sequences which start and stop highlighting of
+// the matching bracket: the standout mode or bold.
+func bracketStyle(t *Terminal) (string, string) {
+	smso, _ := t.TermInfo.ApplyCapability("smso")
+	rmso, _ := t.TermInfo.ApplyCapability("rmso")
+	if smso != "" && rmso != "" {
+		return smso, rmso
+	}
+	bold, _ := t.TermInfo.ApplyCapability("bold")
+	sgr0, _ := t.TermInfo.ApplyCapability("sgr0")
+	if bold != "" && sgr0 != "" {
+		return bold, sgr0
+	}
+	return "", ""
+}
+
+// hideBracketLater redraws the edited lines without the highlighting
+// of the matching bracket after bracketTimeout, unless they are redrawn
+// before it. It must be called after each redraw.
+func hideBracketLater(t *Terminal, cmd *SqlCommandBuffer) {
+	if t.bracketTimer != nil {
+		t.bracketTimer.Stop()
+	}
+	t.redraws++
+	if t.bracket < 0 {
+		return
+	}
+	redraws := t.redraws
+	t.bracketTimer = time.AfterFunc(bracketTimeout, func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		if t.redraws == redraws {
+			drawLine(t, cmd, -1, t.suggestion)
+		}
+	})
+}
+
+// insertPair inserts the typed opening symbol with the closing one or
+// skips the typed closing symbol if it is already under the cursor. It
+// returns false if the symbol must be inserted as usual.
+func insertPair(t *Terminal, cmd *SqlCommandBuffer, keys string) bool {
+	if len(keys) != 1 || t.splitter == nil {
+		return false
+	}
+	c := keys[0]
+	tail := cmd.Tail()
+	if len(tail) > 0 && tail[0] == c && (c == ')' || pairs[c] == c) {
+		cmd.Position++
+		placeCursor(t, cmd)
+		return true
+	}
+
+	closing, ok := pairs[c]
+	if !ok {
+		return false
+	}
+	// no pairs in strings and comments and next to words
+	if t.splitter.Unterminated(string(cmd.Text[:cmd.Position])) != "" {
+		return false
+	}
+	if len(tail) > 0 && isWordSymbol(tail[0]) {
+		return false
+	}
+	if closing == c && cmd.Position > cmd.LineStart && isWordSymbol(cmd.Text[cmd.Position-1]) {
+		return false
+	}
+	cmd.Insert([]byte{c, closing})
+	cmd.Position--
+	refreshLine(t, cmd)
+	return true
+}
+
+// isEmptyPair returns true if the cursor is between the opening and
+// the closing symbols of an empty pair.
+func isEmptyPair(cmd *SqlCommandBuffer) bool {
+	if cmd.Position == cmd.LineStart || cmd.Position == cmd.Length() {
+		return false
+	}
+	closing, ok := pairs[cmd.Text[cmd.Position-1]]
+	return ok && cmd.Text[cmd.Position] == closing
+}
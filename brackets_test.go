@@ -0,0 +1,155 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/0xAX/mysql-tools/sql"
+)
+
+// typePairs types the keys with --auto-pairs like handleKey does it.
+// Ctrl-A, Ctrl-B and Backspace are the editing commands.
+func typePairs(terminal *Terminal, cmd *SqlCommandBuffer, keys string) {
+	for _, symbol := range keys {
+		switch symbol {
+		case CTRL_A:
+			beginningOfLine(terminal, cmd)
+		case CTRL_B:
+			moveLeft(terminal, cmd)
+		case BACKSPACE:
+			backspace(terminal, cmd)
+		default:
+			if !insertPair(terminal, cmd, string(symbol)) {
+				insert(terminal, cmd, []byte(string(symbol)))
+			}
+		}
+	}
+}
+
+func TestAutoPairs(t *testing.T) {
+	tests := []struct {
+		keys     string
+		text     string
+		position uint64
+	}{
+		// the closing symbol is inserted after the opening one
+		{"f(", "f()", 2},
+		{"'", "''", 1},
+		{"`a", "`a`", 2},
+		{"((x", "((x))", 3},
+		// the typed closing symbol is skipped
+		{"f()", "f()", 3},
+		{"'a'", "'a'", 3},
+		{"(())", "(())", 4},
+		// no pairs in strings and before or after words
+		{"'it'", "'it'", 4},
+		{"don't", "don't", 5},
+		{"abc\x01(", "(abc", 1},
+		{"/* (", "/* (", 4},
+		// Backspace removes the empty pair
+		{"f(\x7f", "f", 1},
+		{"''\x02\x7f", "", 0},
+		{"f(x\x7f\x7f", "f", 1},
+		{"f()\x7f", "f(", 2},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, "")
+		terminal.AutoPairs = true
+		terminal.splitter = sql.NewSplitter()
+		cmd := &SqlCommandBuffer{}
+		typePairs(terminal, cmd, test.keys)
+		restore()
+		if cmd.String() != test.text || cmd.Position != test.position {
+			t.Errorf("%q: %q at %d, expected %q at %d", test.keys, cmd.String(), cmd.Position, test.text, test.position)
+		}
+	}
+}
+
+func TestIsEmptyPair(t *testing.T) {
+	tests := []struct {
+		text     string
+		position uint64
+		expected bool
+	}{
+		{"()", 1, true},
+		{"f('')", 3, true},
+		{"``", 1, true},
+		{"()", 0, false},
+		{"()", 2, false},
+		{"(x)", 1, false},
+		{")(", 1, false},
+	}
+	for _, test := range tests {
+		if result := isEmptyPair(testBuffer(test.text, test.position)); result != test.expected {
+			t.Errorf("isEmptyPair(%q, %d) = %v, expected %v", test.text, test.position, result, test.expected)
+		}
+	}
+}
+
+func TestMatchingBracket(t *testing.T) {
+	tests := []struct {
+		text     string
+		position uint64
+		expected int
+	}{
+		// the bracket under the cursor
+		{"f(a, (b))", 1, 8},
+		{"f(a, (b))", 5, 7},
+		{"f(a, (b))", 8, 1},
+		// or the one before the cursor
+		{"f(a, (b))", 9, 1},
+		{"f(a, (b))", 2, 8},
+		// quotes of strings
+		{"'a(b'", 0, 4},
+		{"'a(b'", 4, 0},
+		{"'a(b'", 2, -1},
+		// no match
+		{"f(a", 1, -1},
+		{"abc", 1, -1},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, "")
+		terminal.splitter = sql.NewSplitter()
+		result := matchingBracket(terminal, testBuffer(test.text, test.position))
+		restore()
+		if result != test.expected {
+			t.Errorf("matchingBracket(%q, %d) = %d, expected %d", test.text, test.position, result, test.expected)
+		}
+	}
+
+	// brackets of all edited lines are matched, but not the ones of
+	// the executed text
+	terminal, restore := testTerminal(t, "")
+	defer restore()
+	terminal.splitter = sql.NewSplitter()
+	cmd := testBuffer("SELECT (1,", 10)
+	cmd.NewLine()
+	cmd.Insert([]byte("2)"))
+	if result := matchingBracket(terminal, cmd); result != 7 {
+		t.Errorf("matchingBracket(%q) = %d, expected 7", cmd.String(), result)
+	}
+	cmd.LineStart = 8
+	if result := matchingBracket(terminal, cmd); result != -1 {
+		t.Errorf("matchingBracket matched the executed text: %d", result)
+	}
+}
+
+func TestHideBracketLater(t *testing.T) {
+	terminal, restore := testTerminal(t, "")
+	defer restore()
+	cmd := testBuffer("f(x)", 1)
+
+	terminal.bracket = -1
+	hideBracketLater(terminal, cmd)
+	if terminal.bracketTimer != nil {
+		t.Error("timer is started without the highlighted bracket")
+	}
+	terminal.bracket = 3
+	hideBracketLater(terminal, cmd)
+	timer := terminal.bracketTimer
+	hideBracketLater(terminal, cmd)
+	// the timer of the previous redraw is stopped
+	if timer == nil || timer.Stop() || terminal.redraws != 3 {
+		t.Error("hideBracketLater failed")
+	}
+	terminal.bracketTimer.Stop()
+}
@@ -1,10 +1,251 @@
 package main
 
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/0xAX/mysql-tools/wcwidth"
+)
+
 // SqlCommnadBuffer provides interface to current sql command typed
-// by an user.
+// by an user. The command may consist of several lines, all lines of
+// the unterminated statement are edited together.
 type SqlCommandBuffer struct {
-	// Length of current sql command
-	Length uint64
+	// Text of current sql command
+	Text []byte
+	// Start of the edited lines in the text, the text before it is
+	// executed already
+	LineStart uint64
 	// Current position in sql command
 	Position uint64
+	// undo and redo are logs of groups of changes of the edited lines
+	undo [][]bufferEdit
+	redo [][]bufferEdit
+	// grouping is true if changes are added to the last group
+	grouping bool
+	// changing is true between BeginChange and EndChange
+	changing bool
+}
+
+// bufferEdit is a change of the text of the buffer which can be undone.
+type bufferEdit struct {
+	position uint64 // offset of the change in the text
+	removed  string // text which is removed at the position
+	inserted string // text which is inserted at the position
+	cursor   uint64 // position of the cursor before the change
+}
+
+// Length returns length of current sql command.
+func (b *SqlCommandBuffer) Length() uint64 {
+	return uint64(len(b.Text))
+}
+
+// String returns text of current sql command.
+func (b *SqlCommandBuffer) String() string {
+	return string(b.Text)
+}
+
+// Tail returns the text after the current position.
+func (b *SqlCommandBuffer) Tail() []byte {
+	return b.Text[b.Position:]
+}
+
+// Insert inserts the data at the current position.
+func (b *SqlCommandBuffer) Insert(data []byte) {
+	b.record(bufferEdit{position: b.Position, inserted: string(data), cursor: b.Position})
+	text := make([]byte, 0, len(b.Text)+len(data))
+	text = append(text, b.Text[:b.Position]...)
+	text = append(text, data...)
+	b.Text = append(text, b.Text[b.Position:]...)
+	b.Position += uint64(len(data))
+}
+
+// Delete removes a symbol before the current position. It returns
+// false if the position is at the beginning of the edited lines.
+func (b *SqlCommandBuffer) Delete() bool {
+	if b.Position == b.LineStart {
+		return false
+	}
+	b.Remove(b.PreviousSymbol(b.Position), b.Position)
+	return true
+}
+
+// NextSymbol returns position after the symbol (grapheme cluster)
+// which starts at the given position.
+func (b *SqlCommandBuffer) NextSymbol(position uint64) uint64 {
+	return position + uint64(wcwidth.NextGrapheme(string(b.Text[position:])))
+}
+
+// PreviousSymbol returns start of the symbol (grapheme cluster) which
+// ends at the given position. It does not cross the start of the
+// edited lines.
+func (b *SqlCommandBuffer) PreviousSymbol(position uint64) uint64 {
+	return position - uint64(wcwidth.PreviousGrapheme(string(b.Text[b.LineStart:position])))
+}
+
+// Width returns number of terminal columns used by the text between
+// from and to positions.
+func (b *SqlCommandBuffer) Width(from uint64, to uint64) int {
+	return wcwidth.StringWidth(string(b.Text[from:to]))
+}
+
+// Line returns text of the edited lines.
+func (b *SqlCommandBuffer) Line() string {
+	return string(b.Text[b.LineStart:])
+}
+
+// LineBounds returns the start and the end of the edited line which
+// contains the position. The end is the position of the new line
+// symbol or the end of the text.
+func (b *SqlCommandBuffer) LineBounds(position uint64) (uint64, uint64) {
+	start, end := b.LineStart, b.Length()
+	if i := bytes.LastIndexByte(b.Text[b.LineStart:position], '\n'); i >= 0 {
+		start = b.LineStart + uint64(i) + 1
+	}
+	if i := bytes.IndexByte(b.Text[position:], '\n'); i >= 0 {
+		end = position + uint64(i)
+	}
+	return start, end
+}
+
+// SetLine replaces the edited lines with the text and moves the
+// position to the end of it.
+func (b *SqlCommandBuffer) SetLine(text string) {
+	b.record(bufferEdit{position: b.LineStart, removed: b.Line(), inserted: text, cursor: b.Position})
+	b.Text = append(b.Text[:b.LineStart], text...)
+	b.Position = b.Length()
+}
+
+// Remove removes the text between from and to positions of the
+// edited lines and returns it.
+func (b *SqlCommandBuffer) Remove(from uint64, to uint64) string {
+	removed := string(b.Text[from:to])
+	b.record(bufferEdit{position: from, removed: removed, cursor: b.Position})
+	b.Text = append(b.Text[:from], b.Text[to:]...)
+	if b.Position > to {
+		b.Position -= to - from
+	} else if b.Position > from {
+		b.Position = from
+	}
+	return removed
+}
+
+// NewLine appends the new line to the end of the command. The new
+// line is edited with the previous lines of the statement, so changes
+// of them can be undone on it.
+func (b *SqlCommandBuffer) NewLine() {
+	b.Text = append(b.Text, '\n')
+	b.Position = b.Length()
+}
+
+// Reset replaces the command with the given text which is treated
+// as already entered lines.
+func (b *SqlCommandBuffer) Reset(text string) {
+	b.Text = []byte(text)
+	b.Position = b.Length()
+	b.LineStart = b.Position
+	b.clearUndo()
+}
+
+// BeginUndo starts a new group of changes which are undone together.
+// Typed symbols which continue the last insertion of a word are added
+// to its group, so typed words are undone one by one.
+func (b *SqlCommandBuffer) BeginUndo() {
+	if !b.changing {
+		b.grouping = false
+	}
+}
+
+// BeginChange starts a group of changes which lasts until EndChange,
+// e.g. a vi command with the text typed in the insert mode after it.
+func (b *SqlCommandBuffer) BeginChange() {
+	b.grouping = false
+	b.changing = true
+}
+
+// EndChange finishes the group of changes started by BeginChange.
+func (b *SqlCommandBuffer) EndChange() {
+	b.grouping = false
+	b.changing = false
+}
+
+// Undo reverts the last group of changes of the edited lines. It
+// returns false if there is nothing to undo.
+func (b *SqlCommandBuffer) Undo() bool {
+	if len(b.undo) == 0 {
+		return false
+	}
+	group := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+	for i := len(group) - 1; i >= 0; i-- {
+		edit := group[i]
+		b.replace(edit.position, uint64(len(edit.inserted)), edit.removed)
+	}
+	b.Position = group[0].cursor
+	b.redo = append(b.redo, group)
+	b.grouping = false
+	return true
+}
+
+// Redo applies the last undone group of changes again. It returns
+// false if there is nothing to redo.
+func (b *SqlCommandBuffer) Redo() bool {
+	if len(b.redo) == 0 {
+		return false
+	}
+	group := b.redo[len(b.redo)-1]
+	b.redo = b.redo[:len(b.redo)-1]
+	for _, edit := range group {
+		b.replace(edit.position, uint64(len(edit.removed)), edit.inserted)
+		b.Position = edit.position + uint64(len(edit.inserted))
+	}
+	b.undo = append(b.undo, group)
+	b.grouping = false
+	return true
+}
+
+// record adds the change to the undo log. The redo log is discarded
+// as the undone changes can not be applied after the new one.
+func (b *SqlCommandBuffer) record(edit bufferEdit) {
+	if edit.removed == edit.inserted {
+		return
+	}
+	b.redo = nil
+	if b.grouping && len(b.undo) > 0 {
+		group := b.undo[len(b.undo)-1]
+		b.undo[len(b.undo)-1] = append(group, edit)
+		return
+	}
+	b.grouping = true
+
+	if len(b.undo) > 0 && !b.changing {
+		group := b.undo[len(b.undo)-1]
+		last := &group[len(group)-1]
+		if len(group) == 1 && last.removed == "" && edit.removed == "" && last.inserted != "" &&
+			utf8.RuneCountInString(edit.inserted) == 1 &&
+			last.position+uint64(len(last.inserted)) == edit.position &&
+			!(isSpaceSymbol(last.inserted[len(last.inserted)-1]) && !isSpaceSymbol(edit.inserted[0])) {
+			last.inserted += edit.inserted
+			return
+		}
+	}
+	b.undo = append(b.undo, []bufferEdit{edit})
+}
+
+// replace replaces length bytes at the position with the text without
+// recording of the change.
+func (b *SqlCommandBuffer) replace(position uint64, length uint64, text string) {
+	result := make([]byte, 0, len(b.Text)-int(length)+len(text))
+	result = append(result, b.Text[:position]...)
+	result = append(result, text...)
+	b.Text = append(result, b.Text[position+length:]...)
+}
+
+// clearUndo discards the undo and redo logs when the command is
+// replaced.
+func (b *SqlCommandBuffer) clearUndo() {
+	b.undo = nil
+	b.redo = nil
+	b.grouping = false
+	b.changing = false
 }
@@ -0,0 +1,149 @@
+package main
+
+import "testing"
+
+// typeText inserts the text symbol by symbol like it is typed.
+func typeText(cmd *SqlCommandBuffer, text string) {
+	for _, symbol := range text {
+		cmd.BeginUndo()
+		cmd.Insert([]byte(string(symbol)))
+	}
+}
+
+func TestUndo(t *testing.T) {
+	tests := []struct {
+		name   string
+		edit   func(cmd *SqlCommandBuffer)
+		undo   []string
+		cursor uint64
+	}{
+		{"typed words are undone one by one", func(cmd *SqlCommandBuffer) {
+			typeText(cmd, "abc def")
+		}, []string{"abc ", ""}, 0},
+		{"insertion in the middle", func(cmd *SqlCommandBuffer) {
+			typeText(cmd, "abc")
+			cmd.Position = 2
+			typeText(cmd, "xy")
+		}, []string{"abc", ""}, 0},
+		{"deleted symbols are undone one by one", func(cmd *SqlCommandBuffer) {
+			typeText(cmd, "abc")
+			cmd.BeginUndo()
+			cmd.Delete()
+			cmd.BeginUndo()
+			cmd.Delete()
+		}, []string{"ab", "abc", ""}, 0},
+		{"killed text", func(cmd *SqlCommandBuffer) {
+			typeText(cmd, "abc def")
+			cmd.BeginUndo()
+			cmd.Remove(3, 7)
+		}, []string{"abc def", "abc ", ""}, 0},
+		{"changes of one command are undone together", func(cmd *SqlCommandBuffer) {
+			typeText(cmd, "abc ")
+			cmd.BeginUndo()
+			cmd.Remove(0, 1)
+			cmd.Insert([]byte("x"))
+			cmd.Insert([]byte("yz"))
+		}, []string{"abc ", ""}, 0},
+		{"changes between BeginChange and EndChange are undone together", func(cmd *SqlCommandBuffer) {
+			typeText(cmd, "abc ")
+			cmd.BeginChange()
+			cmd.Remove(0, 1)
+			typeText(cmd, "xy z")
+			cmd.EndChange()
+		}, []string{"abc ", ""}, 0},
+		{"recalled line", func(cmd *SqlCommandBuffer) {
+			typeText(cmd, "abc")
+			cmd.BeginUndo()
+			cmd.SetLine("SELECT 1;")
+			cmd.Position = 0
+		}, []string{"abc", ""}, 0},
+	}
+	for _, test := range tests {
+		cmd := &SqlCommandBuffer{}
+		test.edit(cmd)
+		for _, expected := range test.undo {
+			cmd.BeginUndo()
+			if !cmd.Undo() || cmd.String() != expected {
+				t.Errorf("%s: undo %q, expected %q", test.name, cmd.String(), expected)
+			}
+		}
+		if cmd.Undo() {
+			t.Errorf("%s: undo of empty log", test.name)
+		}
+		if cmd.Position != test.cursor {
+			t.Errorf("%s: cursor at %d, expected %d", test.name, cmd.Position, test.cursor)
+		}
+	}
+}
+
+func TestUndoCursor(t *testing.T) {
+	cmd := &SqlCommandBuffer{}
+	typeText(cmd, "abc def")
+	cmd.Position = 1
+	cmd.BeginUndo()
+	cmd.Remove(4, 7)
+	cmd.Undo()
+	if cmd.String() != "abc def" || cmd.Position != 1 {
+		t.Errorf("Undo failed: %q at %d", cmd.String(), cmd.Position)
+	}
+}
+
+func TestRedo(t *testing.T) {
+	cmd := &SqlCommandBuffer{}
+	typeText(cmd, "abc def")
+	cmd.BeginUndo()
+	cmd.Undo()
+	cmd.BeginUndo()
+	cmd.Undo()
+	for _, expected := range []string{"abc ", "abc def"} {
+		cmd.BeginUndo()
+		if !cmd.Redo() || cmd.String() != expected {
+			t.Errorf("Redo failed: %q, expected %q", cmd.String(), expected)
+		}
+	}
+	if cmd.Redo() {
+		t.Error("Redo of empty log")
+	}
+	if cmd.Position != cmd.Length() {
+		t.Errorf("Redo moved the cursor to %d", cmd.Position)
+	}
+
+	// a new change discards the redo log
+	cmd.BeginUndo()
+	cmd.Undo()
+	typeText(cmd, "x")
+	if cmd.Redo() || cmd.String() != "abc x" {
+		t.Errorf("Redo after a change: %q", cmd.String())
+	}
+	// and the cursor motions do not
+	cmd.BeginUndo()
+	cmd.Undo()
+	cmd.Position = 0
+	if !cmd.Redo() || cmd.String() != "abc x" {
+		t.Errorf("Redo after a motion: %q", cmd.String())
+	}
+}
+
+func TestUndoNewLine(t *testing.T) {
+	cmd := &SqlCommandBuffer{}
+	cmd.Insert([]byte("SELECT"))
+	cmd.NewLine()
+	cmd.BeginUndo()
+	cmd.Insert([]byte("1"))
+	for _, expected := range []string{"SELECT\n", "\n", "\n"} {
+		cmd.BeginUndo()
+		cmd.Undo()
+		if cmd.String() != expected {
+			t.Errorf("Undo failed: %q, expected %q", cmd.String(), expected)
+		}
+	}
+	cmd.Redo()
+	if cmd.String() != "SELECT\n" {
+		t.Errorf("Redo failed: %q", cmd.String())
+	}
+
+	cmd.Reset("SELECT 1;\n")
+	if cmd.Undo() || cmd.Redo() {
+		t.Error("Reset must clear undo")
+	}
+}
@@ -1,5 +1,7 @@
 package main
 
+import "github.com/0xAX/mysql-tools/mysql"
+
 var (
 	// Databases is list of databases avaliable for current user.
 	Databases []string = []string{"mysql"}
@@ -12,3 +14,20 @@ func DatabaseList() func(line string) []string {
 		return Databases
 	}
 }
+
+// LoadDatabases fills Databases with the list of databases which
+// are avaliable for current user on the server.
+func LoadDatabases(conn *mysql.Conn) error {
+	results, err := conn.Query("SHOW DATABASES")
+	if err != nil {
+		return err
+	}
+
+	databases := []string{}
+	for _, row := range results[0].Rows {
+		databases = append(databases, row[0].Data)
+	}
+	Databases = databases
+
+	return nil
+}
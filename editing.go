@@ -0,0 +1,201 @@
+package main
+
+import "os"
+
+// isWordSymbol returns true if the symbol is a part of a word for
+// the Alt-B, Alt-F and Alt-D commands.
+func isWordSymbol(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// isSpaceSymbol returns true if the symbol separates words for the
+// Ctrl-W command.
+func isSpaceSymbol(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// previousWord returns the start of the word before the position.
+func previousWord(cmd *SqlCommandBuffer, isWord func(byte) bool) uint64 {
+	position := cmd.Position
+	for position > cmd.LineStart && !isWord(cmd.Text[position-1]) {
+		position--
+	}
+	for position > cmd.LineStart && isWord(cmd.Text[position-1]) {
+		position--
+	}
+	return position
+}
+
+// nextWord returns the end of the word after the position.
+func nextWord(cmd *SqlCommandBuffer) uint64 {
+	position := cmd.Position
+	for position < cmd.Length() && !isWordSymbol(cmd.Text[position]) {
+		position++
+	}
+	for position < cmd.Length() && isWordSymbol(cmd.Text[position]) {
+		position++
+	}
+	return position
+}
+
+// beginningOfLine moves the cursor to the beginning of the line (Ctrl-A).
+func beginningOfLine(t *Terminal, cmd *SqlCommandBuffer) {
+	cmd.Position, _ = cmd.LineBounds(cmd.Position)
+	refreshLine(t, cmd)
+}
+
+// endOfLine moves the cursor to the end of the line or accepts the
+// suggestion at the end of the line (Ctrl-E).
+func endOfLine(t *Terminal, cmd *SqlCommandBuffer) {
+	if acceptSuggestion(t, cmd, false) {
+		return
+	}
+	_, cmd.Position = cmd.LineBounds(cmd.Position)
+	refreshLine(t, cmd)
+}
+
+// previousLine moves the cursor to the previous line of the statement.
+// It returns false on the first line.
+func previousLine(t *Terminal, cmd *SqlCommandBuffer) bool {
+	start, _ := cmd.LineBounds(cmd.Position)
+	if start == cmd.LineStart {
+		return false
+	}
+	moveToLine(t, cmd, start-1)
+	return true
+}
+
+// nextLine moves the cursor to the next line of the statement. It
+// returns false on the last line.
+func nextLine(t *Terminal, cmd *SqlCommandBuffer) bool {
+	_, end := cmd.LineBounds(cmd.Position)
+	if end == cmd.Length() {
+		return false
+	}
+	moveToLine(t, cmd, end+1)
+	return true
+}
+
+// moveToLine moves the cursor to the line which contains the position.
+// The cursor keeps its column if the line is long enough.
+func moveToLine(t *Terminal, cmd *SqlCommandBuffer, position uint64) {
+	start, _ := cmd.LineBounds(cmd.Position)
+	column := cmd.Width(start, cmd.Position)
+	start, end := cmd.LineBounds(position)
+	position = start
+	for position < end && cmd.Width(start, cmd.NextSymbol(position)) <= column {
+		position = cmd.NextSymbol(position)
+	}
+	cmd.Position = position
+	placeCursor(t, cmd)
+}
+
+// backwardWord moves the cursor to the start of the previous word (Alt-B).
+func backwardWord(t *Terminal, cmd *SqlCommandBuffer) {
+	cmd.Position = previousWord(cmd, isWordSymbol)
+	refreshLine(t, cmd)
+}
+
+// forwardWord moves the cursor to the end of the next word or accepts
+// the next word of the suggestion at the end of the line (Alt-F).
+func forwardWord(t *Terminal, cmd *SqlCommandBuffer) {
+	if acceptSuggestion(t, cmd, true) {
+		return
+	}
+	cmd.Position = nextWord(cmd)
+	refreshLine(t, cmd)
+}
+
+// killLine removes the text from the cursor to the end of the line
+// (Ctrl-K) and returns it. At the end of the line the next line is
+// joined to it.
+func killLine(t *Terminal, cmd *SqlCommandBuffer) string {
+	_, end := cmd.LineBounds(cmd.Position)
+	if end == cmd.Position && end < cmd.Length() {
+		end++
+	}
+	killed := cmd.Remove(cmd.Position, end)
+	refreshLine(t, cmd)
+	return killed
+}
+
+// unixLineDiscard removes the text from the beginning of the line to
+// the cursor (Ctrl-U) and returns it.
+func unixLineDiscard(t *Terminal, cmd *SqlCommandBuffer) string {
+	start, _ := cmd.LineBounds(cmd.Position)
+	killed := cmd.Remove(start, cmd.Position)
+	refreshLine(t, cmd)
+	return killed
+}
+
+// unixWordRubout removes the whitespace delimited word before the
+// cursor (Ctrl-W) and returns it.
+func unixWordRubout(t *Terminal, cmd *SqlCommandBuffer) string {
+	isWord := func(c byte) bool { return !isSpaceSymbol(c) }
+	killed := cmd.Remove(previousWord(cmd, isWord), cmd.Position)
+	refreshLine(t, cmd)
+	return killed
+}
+
+// killWord removes the text from the cursor to the end of the next
+// word (Alt-D) and returns it.
+func killWord(t *Terminal, cmd *SqlCommandBuffer) string {
+	killed := cmd.Remove(cmd.Position, nextWord(cmd))
+	refreshLine(t, cmd)
+	return killed
+}
+
+// deleteChar removes the symbol under the cursor (Ctrl-D).
+func deleteChar(t *Terminal, cmd *SqlCommandBuffer) {
+	if cmd.Position == cmd.Length() {
+		return
+	}
+	cmd.Remove(cmd.Position, cmd.NextSymbol(cmd.Position))
+	refreshLine(t, cmd)
+}
+
+// transposeChars swaps the symbol before the cursor with the symbol
+// under the cursor and moves the cursor forward (Ctrl-T). At the end
+// of the line the last two symbols are swapped.
+func transposeChars(t *Terminal, cmd *SqlCommandBuffer) {
+	if cmd.Position == cmd.LineStart {
+		return
+	}
+	if cmd.Position == cmd.Length() {
+		cmd.Position = cmd.PreviousSymbol(cmd.Position)
+		if cmd.Position == cmd.LineStart {
+			cmd.Position = cmd.Length()
+			return
+		}
+	}
+	previous := cmd.PreviousSymbol(cmd.Position)
+	next := cmd.NextSymbol(cmd.Position)
+	symbol := cmd.Remove(previous, cmd.Position)
+	cmd.Position = next - uint64(len(symbol))
+	cmd.Insert([]byte(symbol))
+	refreshLine(t, cmd)
+}
+
+// undo reverts the last change of the line (Ctrl-_ or Ctrl-X Ctrl-U).
+func undo(t *Terminal, cmd *SqlCommandBuffer) {
+	if !cmd.Undo() {
+		return
+	}
+	refreshLine(t, cmd)
+}
+
+// redo applies the last undone change again (Ctrl-^).
+func redo(t *Terminal, cmd *SqlCommandBuffer) {
+	if !cmd.Redo() {
+		return
+	}
+	refreshLine(t, cmd)
+}
+
+// clearScreen clears the screen and redraws the edited lines (Ctrl-L).
+func clearScreen(t *Terminal, cmd *SqlCommandBuffer) {
+	capability, _ := t.TermInfo.ApplyCapability("clear")
+	os.Stdout.Write([]byte(capability))
+	t.cursorRow = 0
+	refreshLine(t, cmd)
+}
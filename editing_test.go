@@ -0,0 +1,140 @@
+package main
+
+import "testing"
+
+func TestEmacsCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  func(*Terminal, *SqlCommandBuffer)
+		text     string
+		position uint64
+		line     string
+		cursor   uint64
+	}{
+		// Ctrl-A and Ctrl-E
+		{"beginningOfLine", beginningOfLine, "SELECT 1", 4, "SELECT 1", 0},
+		{"endOfLine", endOfLine, "SELECT 1", 4, "SELECT 1", 8},
+		// Ctrl-B and Ctrl-F
+		{"moveLeft", moveLeft, "SELECT 1", 4, "SELECT 1", 3},
+		{"moveLeft", moveLeft, "SELECT 1", 0, "SELECT 1", 0},
+		{"moveRight", moveRight, "SELECT 1", 4, "SELECT 1", 5},
+		{"moveRight", moveRight, "SELECT 1", 8, "SELECT 1", 8},
+		{"moveLeft", moveLeft, "表a", 3, "表a", 0},
+		{"moveRight", moveRight, "表a", 0, "表a", 3},
+		{"moveRight", moveRight, "e\u0301x", 0, "e\u0301x", 3},
+		// Alt-B and Alt-F
+		{"backwardWord", backwardWord, "SELECT a_b, c", 13, "SELECT a_b, c", 12},
+		{"backwardWord", backwardWord, "SELECT a_b, c", 12, "SELECT a_b, c", 7},
+		{"backwardWord", backwardWord, "SELECT a_b, c", 9, "SELECT a_b, c", 7},
+		{"forwardWord", forwardWord, "SELECT a_b, c", 0, "SELECT a_b, c", 6},
+		{"forwardWord", forwardWord, "SELECT a_b, c", 6, "SELECT a_b, c", 10},
+		// Ctrl-K, Ctrl-U, Ctrl-W and Alt-D
+		{"killLine", func(t *Terminal, cmd *SqlCommandBuffer) { killLine(t, cmd) }, "SELECT 1", 6, "SELECT", 6},
+		{"unixLineDiscard", func(t *Terminal, cmd *SqlCommandBuffer) { unixLineDiscard(t, cmd) }, "SELECT 1", 6, " 1", 0},
+		{"unixWordRubout", func(t *Terminal, cmd *SqlCommandBuffer) { unixWordRubout(t, cmd) }, "SELECT a.b, c", 11, "SELECT  c", 7},
+		{"unixWordRubout", func(t *Terminal, cmd *SqlCommandBuffer) { unixWordRubout(t, cmd) }, "SELECT 1  ", 10, "SELECT ", 7},
+		{"killWord", func(t *Terminal, cmd *SqlCommandBuffer) { killWord(t, cmd) }, "SELECT a_b, c", 6, "SELECT, c", 6},
+		// Ctrl-D
+		{"deleteChar", deleteChar, "SELECT 1", 0, "ELECT 1", 0},
+		{"deleteChar", deleteChar, "SELECT 1", 8, "SELECT 1", 8},
+		{"deleteChar", deleteChar, "да", 0, "а", 0},
+		{"deleteChar", deleteChar, "e\u0301x", 0, "x", 0},
+		// Ctrl-T
+		{"transposeChars", transposeChars, "SELETC", 5, "SELECT", 6},
+		{"transposeChars", transposeChars, "SELETC", 6, "SELECT", 6},
+		{"transposeChars", transposeChars, "SELECT", 0, "SELECT", 0},
+		{"transposeChars", transposeChars, "ад", 4, "да", 4},
+		{"transposeChars", transposeChars, "x表y", 4, "xy表", 5},
+		{"transposeChars", transposeChars, "表", 3, "表", 3},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, "")
+		cmd := testBuffer(test.text, test.position)
+		test.command(terminal, cmd)
+		restore()
+		if cmd.Line() != test.line || cmd.Position != test.cursor {
+			t.Errorf("%s(%q, %d): %q at %d, expected %q at %d", test.name, test.text, test.position,
+				cmd.Line(), cmd.Position, test.line, test.cursor)
+		}
+	}
+}
+
+func TestKilledText(t *testing.T) {
+	terminal, restore := testTerminal(t, "")
+	defer restore()
+
+	cmd := testBuffer("SELECT a, b", 9)
+	if killed := killLine(terminal, cmd); killed != " b" {
+		t.Errorf("killLine returned %q", killed)
+	}
+	if killed := unixWordRubout(terminal, cmd); killed != "a," {
+		t.Errorf("unixWordRubout returned %q", killed)
+	}
+	cmd.Position = 0
+	if killed := killWord(terminal, cmd); killed != "SELECT" {
+		t.Errorf("killWord returned %q", killed)
+	}
+	if killed := unixLineDiscard(terminal, cmd); killed != "" {
+		t.Errorf("unixLineDiscard returned %q", killed)
+	}
+}
+
+func TestMultiLineEditing(t *testing.T) {
+	const text = "SELECT a,\n  bc\nFROM t"
+	type command = func(*Terminal, *SqlCommandBuffer)
+	up, down, home := command(historyPrevious), command(historyNext), command(beginningOfLine)
+	kill := func(t *Terminal, cmd *SqlCommandBuffer) { t.KillRing.Kill(killLine(t, cmd), false) }
+	discard := func(t *Terminal, cmd *SqlCommandBuffer) { t.KillRing.Kill(unixLineDiscard(t, cmd), true) }
+	tests := []struct {
+		text     string
+		commands []command
+		result   string
+		position uint64
+	}{
+		// the cursor keeps its column on the previous and next lines
+		{text, []command{up}, text, 14},
+		{text, []command{up, up}, text, 4},
+		{text, []command{up, up, up}, text, 4},
+		{text, []command{up, up, down}, text, 14},
+		{text, []command{up, up, down, down}, text, 19},
+		{text, []command{home, up}, text, 10},
+		{"日本\nabcd", []command{up}, "日本\nabcd", 6},
+		{"日本\nabcd", []command{home, moveRight, up}, "日本\nabcd", 0},
+		{"日本\nabcd", []command{home, moveRight, moveRight, moveRight, up}, "日本\nabcd", 3},
+		// moves and deletions cross the line ends
+		{text, []command{home, moveLeft}, text, 14},
+		{text, []command{home, backspace}, "SELECT a,\n  bcFROM t", 14},
+		{text, []command{up, moveRight}, text, 15},
+		{text, []command{up, endOfLine}, text, 14},
+		// kills work with the current line
+		{text, []command{up, up, kill}, "SELE\n  bc\nFROM t", 4},
+		{text, []command{up, up, kill, kill}, "SELE  bc\nFROM t", 4},
+		{text, []command{home, kill, kill}, "SELECT a,\n  bc\n", 15},
+		{text, []command{up, discard}, "SELECT a,\n\nFROM t", 10},
+		{text, []command{up, home, discard}, text, 10},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, "")
+		cmd := testBuffer(test.text, uint64(len(test.text)))
+		for _, command := range test.commands {
+			terminal.KillRing.Begin()
+			command(terminal, cmd)
+		}
+		restore()
+		if cmd.String() != test.result || cmd.Position != test.position {
+			t.Errorf("%q: %q at %d, expected %q at %d", test.text, cmd.String(), cmd.Position, test.result, test.position)
+		}
+	}
+
+	// consecutive kills of the lines are merged
+	terminal, restore := testTerminal(t, "")
+	defer restore()
+	cmd := testBuffer(text, 4)
+	for i := 0; i < 2; i++ {
+		terminal.KillRing.Begin()
+		kill(terminal, cmd)
+	}
+	if len(terminal.KillRing.entries) != 1 || terminal.KillRing.entries[0] != "CT a,\n" {
+		t.Errorf("kill-line failed: %q", terminal.KillRing.entries)
+	}
+}
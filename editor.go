@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/0xAX/mysql-tools/termios"
+)
+
+// defaultEditor is used if neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// editorCommand returns the editor from $VISUAL or $EDITOR.
+func editorCommand() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(env); editor != "" {
+			return editor
+		}
+	}
+	return defaultEditor
+}
+
+// editText writes the text to a temporary file, opens it in the editor
+// and returns the edited text. The terminal is restored to the original
+// mode while the editor runs.
+func editText(t *Terminal, text string) (string, error) {
+	file, err := ioutil.TempFile("", "mysql-cli")
+	if err != nil {
+		return text, err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(text + "\n")
+	file.Close()
+	if err != nil {
+		return text, err
+	}
+
+	args := strings.Fields(editorCommand())
+	command := exec.Command(args[0], append(args[1:], file.Name())...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	enableBracketedPaste(false)
+	termios.Reset(t.outputFd, t.TermCtrl)
+	err = command.Run()
+	termios.TcSetAttr(t.outputFd, termios.TCSETSF, t.TermCtrl)
+	enableBracketedPaste(true)
+	if err != nil {
+		return text, err
+	}
+
+	edited, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return text, err
+	}
+	return strings.TrimRight(string(edited), "\n"), nil
+}
+
+// editStatement opens the statement in the editor and returns the
+// edited text. The last executed statement is edited if the statement
+// is empty like \e of mysql does.
+func editStatement(t *Terminal, text string) string {
+	if strings.TrimSpace(text) == "" {
+		text = t.lastStatement
+	}
+	edited, err := editText(t, text)
+	if err != nil {
+		t.writeOutput("\nERROR: " + err.Error() + "\n")
+	}
+	return edited
+}
+
+// loadEdited replaces the buffer with the edited text and redraws it
+// on the new line.
+func loadEdited(t *Terminal, cmd *SqlCommandBuffer, text string) {
+	// all lines of the statement are edited, the change is undone
+	// like other ones if there are no executed lines before them
+	if cmd.LineStart > 0 {
+		cmd.Reset("")
+	}
+	cmd.SetLine(text)
+	t.prompt = DefaultPrompt + " "
+	t.cursorRow = 0
+	refreshLine(t, cmd)
+}
+
+// editBuffer opens the statement in the editor and loads the edited
+// text back to the buffer.
+func editBuffer(t *Terminal, cmd *SqlCommandBuffer) {
+	text := editStatement(t, cmd.String())
+	os.Stdout.Write([]byte("\r\n"))
+	loadEdited(t, cmd, text)
+}
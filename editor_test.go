@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0xAX/mysql-tools/mysql"
+	"github.com/0xAX/mysql-tools/sql"
+)
+
+// testEditor sets the editor to the script which replaces "foo" with
+// "EDITED" and the directory of temporary files to an empty directory
+// which is returned.
+func testEditor(t *testing.T) (string, func()) {
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "editor.sh")
+	err := ioutil.WriteFile(editor, []byte("#!/bin/sh\nsed -i 's/foo/EDITED/g' \"$1\"\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(dir, "tmp")
+	if err := os.Mkdir(tmp, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{}
+	for _, name := range []string{"VISUAL", "EDITOR", "TMPDIR"} {
+		env[name] = os.Getenv(name)
+	}
+	os.Unsetenv("VISUAL")
+	os.Setenv("EDITOR", editor)
+	os.Setenv("TMPDIR", tmp)
+	return tmp, func() {
+		for name, value := range env {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+func TestEditText(t *testing.T) {
+	tmp, restoreEditor := testEditor(t)
+	defer restoreEditor()
+	terminal, restore := testTerminal(t, "")
+	defer restore()
+
+	edited, err := editText(terminal, "SELECT foo\nFROM t")
+	if err != nil {
+		t.Fatal("editText failed", err)
+	}
+	if edited != "SELECT EDITED\nFROM t" {
+		t.Errorf("editText returned %q", edited)
+	}
+	// the temporary file is removed
+	if files, _ := ioutil.ReadDir(tmp); len(files) != 0 {
+		t.Errorf("%d temporary files are left", len(files))
+	}
+
+	os.Setenv("EDITOR", "false")
+	edited, err = editText(terminal, "SELECT foo")
+	if err == nil || edited != "SELECT foo" {
+		t.Errorf("editText with failed editor: %q, %v", edited, err)
+	}
+	if files, _ := ioutil.ReadDir(tmp); len(files) != 0 {
+		t.Errorf("%d temporary files are left", len(files))
+	}
+}
+
+func TestEditCommand(t *testing.T) {
+	_, restoreEditor := testEditor(t)
+	defer restoreEditor()
+
+	tests := []struct {
+		text     string
+		last     string
+		expected string
+	}{
+		{"SELECT foo\\e", "", "SELECT EDITED"},
+		{"SELECT\nfoo\\e", "", "SELECT\nEDITED"},
+		// the following statements are kept and not executed
+		{"SELECT foo\\e SELECT 2; SELECT foo", "", "SELECT EDITED\nSELECT 2;\nSELECT foo"},
+		// the last statement is edited with the empty buffer
+		{"\\e", "SELECT foo", "SELECT EDITED"},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, "")
+		terminal.Conn = &mysql.Conn{}
+		terminal.splitter = sql.NewSplitter()
+		terminal.lastStatement = test.last
+		cmd := &SqlCommandBuffer{}
+		cmd.Insert([]byte(test.text))
+		cmd.NewLine()
+		running := terminal.executeBuffer(cmd, terminal.splitter)
+		restore()
+		if !running || cmd.String() != test.expected {
+			t.Errorf("%q: %q, expected %q", test.text, cmd.String(), test.expected)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/0xAX/mysql-tools/sql"
+)
+
+// isQuitCommand returns true if the text is a command which stops
+// mysql-cli. Such commands do not need a delimiter.
+func isQuitCommand(text string) bool {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "quit", "exit", "\\q":
+		return true
+	}
+	return false
+}
+
+// execute executes the statement and prints its result. It returns
+// false if mysql-cli must be stopped.
+func (t *Terminal) execute(statement sql.Statement) bool {
+	text := statement.Text
+	if isQuitCommand(text) {
+		return false
+	}
+	if text == "" {
+		t.writeOutput("ERROR: No query specified\n\n")
+		return true
+	}
+	t.lastStatement = text
+
+	fields := strings.Fields(text)
+	if strings.ToLower(fields[0]) == "use" {
+		if len(fields) < 2 {
+			t.writeOutput("ERROR: USE must be followed by a database name\n")
+			return true
+		}
+		err := t.Conn.InitDB(strings.Trim(fields[1], "`"))
+		if err != nil {
+			t.writeOutput(formatError(err))
+			return true
+		}
+		t.writeOutput("Database changed\n")
+		return true
+	}
+
+	start := time.Now()
+	results, err := t.Conn.Query(text)
+	elapsed := time.Since(start)
+	for _, result := range results {
+		t.writeOutput(formatResult(result, statement.Terminator == "\\G", elapsed))
+	}
+	if err != nil {
+		t.writeOutput(formatError(err))
+	}
+	return true
+}
+
+// writeOutput writes the text to the terminal. The terminal is in the
+// raw mode, so new lines are written as "\r\n".
+func (t *Terminal) writeOutput(text string) {
+	t.outputFd.Write([]byte(strings.Replace(text, "\n", "\r\n", -1)))
+}
@@ -1,25 +1,116 @@
 package main
 
-import "flag"
+import "github.com/0xAX/mysql-tools/options"
+
+// commandLine is the set of mysql-cli command line options
+var commandLine = options.NewOptionSet("mysql-cli")
+
+// optionGroups are groups of option files which are read by mysql-cli
+var optionGroups = []string{"client", "mysql", "mysql-cli"}
 
 var (
-	bind_address = flag.String("bind-address", "", `On a computer having multiple network interfaces, 
+	bind_address = commandLine.String("bind-address", 0, "", `On a computer having multiple network interfaces, 
         use this option to select which interface to use 
         for connecting to the MySQL server.`)
 
-	db = flag.String("database,-D", "mysql", `The database to use. This is useful primarily in 
+	host = commandLine.String("host", 'h', "", `Connect to the MySQL server on the given host. The 
+        localhost means connection via the Unix socket.`)
+
+	port = commandLine.Int("port", 'P', 0, `For TCP/IP connections, the port number to use.`)
+
+	socket = commandLine.String("socket", 'S', "", `For connections to localhost, the Unix socket file 
+        to use.`)
+
+	protocol = commandLine.String("protocol", 0, "", `The transport protocol to use for connecting to the 
+        server: TCP or SOCKET. It is useful when the other 
+        connection parameters normally result in use of a 
+        protocol other than the one you want.`)
+
+	db = commandLine.String("database", 'D', "mysql", `The database to use. This is useful primarily in 
         an option file.`)
 
-	user = flag.String("user,-u", "root", `The MySQL user name to use when connecting to the 
+	user = commandLine.String("user", 'u', "root", `The MySQL user name to use when connecting to the 
         server.`)
 
-	password = flag.String("password,-p", "", `The password to use when connecting to the server. 
+	password = commandLine.OptionalString("password", 'p', `The password to use when connecting to the server. 
         If you use the short option form (-p), you cannot 
         have a space between the option and the password. 
         If you omit the password value following the 
         --password or -p option on the command line, mysql 
         prompts for one.`)
 
-	history_file = flag.String("history", "", `The fill will be used as history for users
-	commands.`)
+	server_public_key_path = commandLine.String("server-public-key-path", 0, "", `The path name to a file containing a client-side 
+        copy of the public key required by the server for 
+        RSA key pair-based password exchange.`)
+
+	get_server_public_key = commandLine.Bool("get-server-public-key", 0, false, `Request from the server the public key required 
+        for RSA key pair-based password exchange.`)
+
+	ssl_mode = commandLine.String("ssl-mode", 0, "", `The desired security state of the connection to the 
+        server: DISABLED, PREFERRED (default), REQUIRED, 
+        VERIFY_CA or VERIFY_IDENTITY.`)
+
+	ssl_ca = commandLine.String("ssl-ca", 0, "", `The path name of the Certificate Authority (CA) 
+        certificate file in PEM format. If it is given and 
+        --ssl-mode is not, VERIFY_CA mode is used.`)
+
+	ssl_cert = commandLine.String("ssl-cert", 0, "", `The path name of the client public key certificate 
+        file in PEM format.`)
+
+	ssl_key = commandLine.String("ssl-key", 0, "", `The path name of the client private key file in 
+        PEM format.`)
+
+	tls_version = commandLine.String("tls-version", 0, "", `The permissible TLS protocols for encrypted 
+        connections. The value is a list of one or more 
+        comma-separated protocol names: TLSv1, TLSv1.1, 
+        TLSv1.2, TLSv1.3.`)
+
+	history_file = commandLine.String("history", 0, "", `The file will be used as history for users 
+        commands. The default is the MYSQL_HISTFILE 
+        enviroment variable or ~/.mysql_history.`)
+
+	histignore = commandLine.String("histignore", 0, "", `A list of one or more colon-separated patterns 
+        specifying statements to ignore for logging 
+        purposes. These patterns are added to the default 
+        pattern list ("*IDENTIFIED*:*PASSWORD*").`)
+
+	vi = commandLine.Bool("vi", 0, false, `Use vi editing mode of the input line instead of 
+        the default emacs mode.`)
+
+	auto_pairs = commandLine.Bool("auto-pairs", 0, false, `Insert the closing bracket or quote after the 
+        opening one is typed.`)
+
+	no_suggestions = commandLine.Bool("no-suggestions", 0, false, `Do not suggest history entries which start with 
+        the input.`)
+
+	no_color = commandLine.Bool("no-color", 0, false, `Do not highlight syntax of statements in the 
+        input line.`)
+
+	color_theme = commandLine.String("color-theme", 0, "", `Colors of the syntax highlighting as a list of 
+        colon-separated class=attributes entries, e.g. 
+        "keyword=bold,blue:string=green". The classes are 
+        keyword, identifier, string, number, comment and 
+        variable. The attributes are color names, color 
+        numbers (0-255), bright- colors, bold and none.`)
+
+	no_defaults = commandLine.Bool("no-defaults", 0, false, `Do not read default options from any option 
+        file.`)
+
+	defaults_file = commandLine.String("defaults-file", 0, "", `Use only the given option file. If the file does 
+        not exist or is otherwise inaccessible, an error 
+        occurs.`)
+
+	defaults_extra_file = commandLine.String("defaults-extra-file", 0, "", `Read this option file after the global option 
+        file but before the user option file.`)
+
+	defaults_group_suffix = commandLine.String("defaults-group-suffix", 0, "", `Read not only the usual option groups, but also 
+        groups with the usual names and a suffix of str.`)
+
+	login_path = commandLine.String("login-path", 0, "", `Read options from the named login path in the 
+        .mylogin.cnf login path file. A login path is an 
+        option group created by mysql_config_editor.`)
+
+	help = commandLine.Bool("help", '?', false, `Display a help message and exit.`)
+
+	version = commandLine.Bool("version", 'V', false, `Display version information and exit.`)
 )
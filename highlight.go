@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0xAX/mysql-tools/sql"
+	"github.com/0xAX/mysql-tools/terminfo"
+)
+
+// DefaultTheme is the color theme of the syntax highlighting. Themes
+// are colon-separated lists of class=attributes, where attributes are
+// comma-separated color names, color numbers (0-255) and bold.
+const DefaultTheme = "keyword=bold,blue:identifier=cyan:string=green:number=magenta:comment=bright-black:variable=yellow"
+
+// Classes of highlighted tokens
+const (
+	HIGHLIGHT_KEYWORD    = "keyword"
+	HIGHLIGHT_IDENTIFIER = "identifier"
+	HIGHLIGHT_STRING     = "string"
+	HIGHLIGHT_NUMBER     = "number"
+	HIGHLIGHT_COMMENT    = "comment"
+	HIGHLIGHT_VARIABLE   = "variable"
+)
+
+// colorNames are names of the basic colors of terminals (see setaf
+// in terminfo(5)). The bright- prefix adds 8 to the color.
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// Highlighter colors the SQL statements of the input line.
+type Highlighter struct {
+	// styles are sequences of terminal attributes of the classes
+	styles map[string]string
+	// reset turns off all attributes (sgr0)
+	reset string
+}
+
+// NewHighlighter returns highlighter with the given theme which
+// overrides the default one. It returns nil if the terminal does not
+// support colors.
+func NewHighlighter(termInfo *terminfo.Terminfo, theme string) (*Highlighter, error) {
+	colors := int(termInfo.Numbers["colors"])
+	if colors < len(colorNames) || termInfo.Strings["setaf"] == "" {
+		return nil, nil
+	}
+
+	reset, err := termInfo.ApplyCapability("sgr0")
+	if err != nil {
+		return nil, nil
+	}
+
+	h := &Highlighter{styles: map[string]string{}, reset: reset}
+	for _, spec := range []string{DefaultTheme, theme} {
+		if spec == "" {
+			continue
+		}
+		for _, entry := range strings.Split(spec, ":") {
+			err := h.parseStyle(termInfo, colors, entry)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return h, nil
+}
+
+// parseStyle parses class=attributes entry of the theme.
+func (h *Highlighter) parseStyle(termInfo *terminfo.Terminfo, colors int, entry string) error {
+	fields := strings.SplitN(entry, "=", 2)
+	class := strings.TrimSpace(fields[0])
+	switch class {
+	case HIGHLIGHT_KEYWORD, HIGHLIGHT_IDENTIFIER, HIGHLIGHT_STRING, HIGHLIGHT_NUMBER, HIGHLIGHT_COMMENT, HIGHLIGHT_VARIABLE:
+	default:
+		return fmt.Errorf("Unknown class of the color theme: %s", class)
+	}
+
+	style := ""
+	if len(fields) > 1 {
+		for _, attribute := range strings.Split(fields[1], ",") {
+			attribute = strings.TrimSpace(strings.ToLower(attribute))
+			if attribute == "" || attribute == "none" {
+				continue
+			}
+			if attribute == "bold" {
+				bold, _ := termInfo.ApplyCapability("bold")
+				style += bold
+				continue
+			}
+
+			color, ok := parseColor(attribute)
+			if !ok {
+				return fmt.Errorf("Unknown color of the color theme: %s", attribute)
+			}
+			if color >= colors {
+				color = basicColor(color)
+			}
+			if color < 0 {
+				continue
+			}
+			setaf, err := termInfo.ApplyCapability("setaf", color)
+			if err != nil {
+				return err
+			}
+			style += setaf
+		}
+	}
+	h.styles[class] = style
+	return nil
+}
+
+// parseColor returns number of the color given by its name or number.
+func parseColor(name string) (int, bool) {
+	if number, err := strconv.Atoi(name); err == nil {
+		return number, number >= 0 && number < 256
+	}
+
+	bright := strings.HasPrefix(name, "bright-")
+	name = strings.TrimPrefix(name, "bright-")
+	for i, colorName := range colorNames {
+		if name == colorName {
+			if bright {
+				return i + len(colorNames), true
+			}
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// basicColor returns the closest basic color to the color of the
+// 16 or 256 colors palettes (see the xterm-256color palette), or -1
+// if there is no close basic color and the text must not be colored.
+func basicColor(color int) int {
+	switch {
+	case color < 8:
+		return color
+	case color == 8:
+		// bright black (gray) is not visible as black
+		return -1
+	case color < 16:
+		return color - 8
+	case color < 232:
+		// 6x6x6 color cube, components greater than half are set
+		color -= 16
+		red, green, blue := color/36, color/6%6, color%6
+		basic := 0
+		if red >= 3 {
+			basic |= 1
+		}
+		if green >= 3 {
+			basic |= 2
+		}
+		if blue >= 3 {
+			basic |= 4
+		}
+		return basic
+	case color >= 244:
+		// light grays are shown as white
+		return 7
+	}
+	return -1
+}
+
+// Highlight returns the part of the text between the given byte
+// offsets with terminal attributes of its tokens. The text is
+// tokenized from the beginning with the given delimiter, so strings
+// and comments started in the previous lines are highlighted too.
+// Tabs are shown as spaces like lineText does it. Attributes are reset
+// at the ends of lines, so prompts of the next lines are not colored.
+func (h *Highlighter) Highlight(text string, start int, end int, delimiter string) string {
+	tokens, _ := sql.Tokenize(text, delimiter)
+
+	result := ""
+	for _, token := range tokens {
+		from, to := token.Start, token.Start+len(token.Text)
+		if to <= start || from >= end {
+			continue
+		}
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+		s := displayReplacer.Replace(text[from:to])
+
+		style := h.styles[tokenClass(token)]
+		if style == "" {
+			result += s
+			continue
+		}
+		s = strings.Replace(s, "\n", h.reset+"\n"+style, -1)
+		result += style + s + h.reset
+	}
+	return result
+}
+
+// tokenClass returns the class of the token in the color theme.
+func tokenClass(token sql.Token) string {
+	switch token.Type {
+	case sql.TOKEN_WORD:
+		if sql.IsKeyword(token.Text) {
+			return HIGHLIGHT_KEYWORD
+		}
+		return HIGHLIGHT_IDENTIFIER
+	case sql.TOKEN_QUOTED_IDENTIFIER:
+		return HIGHLIGHT_IDENTIFIER
+	case sql.TOKEN_STRING:
+		return HIGHLIGHT_STRING
+	case sql.TOKEN_NUMBER:
+		return HIGHLIGHT_NUMBER
+	case sql.TOKEN_COMMENT:
+		return HIGHLIGHT_COMMENT
+	case sql.TOKEN_VARIABLE:
+		return HIGHLIGHT_VARIABLE
+	case sql.TOKEN_DELIMITER_COMMAND:
+		return HIGHLIGHT_KEYWORD
+	}
+	return ""
+}
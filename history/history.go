@@ -0,0 +1,341 @@
+// history package provides persistent history of statements entered
+// in the mysql-cli.
+package history
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultFile is name of the history file in the home directory
+	DefaultFile = ".mysql_history"
+	// FileEnv is enviroment variable with path to the history file
+	FileEnv = "MYSQL_HISTFILE"
+	// IgnoreEnv is enviroment variable with patterns of statements
+	// which are not saved to the history
+	IgnoreEnv = "MYSQL_HISTIGNORE"
+	// DefaultIgnore are patterns of statements which are never saved
+	// to the history because they may contain passwords
+	DefaultIgnore = "*IDENTIFIED*:*PASSWORD*"
+	// fileHeader is the first line of history files of libedit which
+	// is used by the mysql client
+	fileHeader = "_HiStOrY_V2_"
+)
+
+// History is list of entered statements. Multi-line statements are
+// stored as one entry.
+type History struct {
+	// entries from the oldest to the newest one
+	entries []string
+	// databases which were current when the entries were entered
+	databases []string
+	// database is the current database of the added entries
+	database string
+	// patterns of statements which are not saved to the history
+	ignore []string
+	// path to the history file, history is not saved if it is empty
+	file string
+	// index of the entry returned by Previous/Next
+	position int
+	// the line which was edited before navigation through history
+	line string
+}
+
+// FilePath returns path to the history file. The path given by the
+// --history option is used first, then the path from MYSQL_HISTFILE
+// and then ~/.mysql_history. It returns the empty string if history
+// must not be saved.
+func FilePath(file string) string {
+	if file == "" {
+		file = os.Getenv(FileEnv)
+	}
+	if file == "" {
+		home := os.Getenv("HOME")
+		if home == "" {
+			return ""
+		}
+		file = filepath.Join(home, DefaultFile)
+	}
+	if file == os.DevNull {
+		return ""
+	}
+	return file
+}
+
+// New returns empty history which is saved to the file. The ignore is
+// colon separated list of patterns (see Ignored), DefaultIgnore
+// patterns are always used in addition.
+func New(file string, ignore string) *History {
+	h := &History{file: file}
+	for _, pattern := range strings.Split(DefaultIgnore+":"+ignore, ":") {
+		if pattern != "" {
+			h.ignore = append(h.ignore, pattern)
+		}
+	}
+	return h
+}
+
+// Load reads entries from the history file. A missing file is not an
+// error.
+func (h *History) Load() error {
+	if h.file == "" {
+		return nil
+	}
+
+	file, err := os.Open(h.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if scanner.Text() == "" || scanner.Text() == fileHeader {
+			continue
+		}
+		entry := decodeEntry(scanner.Text())
+		h.append(entry)
+		// the file keeps statements only, so databases of the
+		// entries are restored by USE statements
+		if database, ok := useDatabase(entry); ok {
+			h.database = database
+		}
+	}
+	h.database = ""
+	h.Reset()
+	return scanner.Err()
+}
+
+// Add adds the statement to the history and appends it to the history
+// file. Empty and ignored statements and statements which are equal
+// to the previous entry are skipped.
+func (h *History) Add(entry string) error {
+	defer h.Reset()
+	entry = strings.TrimSpace(entry)
+	if entry == "" || h.Ignored(entry) {
+		return nil
+	}
+	if !h.append(entry) {
+		return nil
+	}
+
+	if h.file == "" {
+		return nil
+	}
+	file, err := os.OpenFile(h.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	line := encodeEntry(entry) + "\n"
+	// new files are started with the libedit header, so they can be
+	// read by the mysql client
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		line = fileHeader + "\n" + line
+	}
+	_, err = file.WriteString(line)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// append adds the entry if it is not equal to the last one.
+func (h *History) append(entry string) bool {
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return false
+	}
+	h.entries = append(h.entries, entry)
+	h.databases = append(h.databases, h.database)
+	return true
+}
+
+// SetDatabase sets the current database which is saved with the added
+// entries.
+func (h *History) SetDatabase(database string) {
+	h.database = database
+}
+
+// Ignored returns true if the statement matches one of the ignore
+// patterns. Patterns are matched case-insensitively against the whole
+// statement, "*" matches any sequence of characters and "?" matches
+// any single character.
+func (h *History) Ignored(entry string) bool {
+	entry = strings.ToUpper(entry)
+	for _, pattern := range h.ignore {
+		if matchPattern(strings.ToUpper(pattern), entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns all entries from the oldest to the newest one.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Previous returns the entry which is older than the last returned
+// one. The line is the currently edited text, it is returned by Next
+// after the newest entry. It returns false if there are no older
+// entries.
+func (h *History) Previous(line string) (string, bool) {
+	if h.position == 0 {
+		return "", false
+	}
+	if h.position == len(h.entries) {
+		h.line = line
+	}
+	h.position--
+	return h.entries[h.position], true
+}
+
+// Next returns the entry which is newer than the last returned one or
+// the line saved by Previous. It returns false if there is no
+// navigation through the history.
+func (h *History) Next() (string, bool) {
+	if h.position == len(h.entries) {
+		return "", false
+	}
+	h.position++
+	if h.position == len(h.entries) {
+		return h.line, true
+	}
+	return h.entries[h.position], true
+}
+
+// Search returns index of the entry which contains the query. The
+// entries are checked from the start index to the oldest entry if
+// backward is true or to the newest entry otherwise. It returns false
+// if there is no such entry.
+func (h *History) Search(query string, start int, backward bool) (int, bool) {
+	step := 1
+	if backward {
+		step = -1
+	}
+	for i := start; i >= 0 && i < len(h.entries); i += step {
+		if strings.Contains(h.entries[i], query) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Suggest returns the newest entry which starts with the prefix and is
+// longer than it. Entries entered in the given database are preferred
+// to the newer entries of other databases. It returns false if there
+// is no such entry.
+func (h *History) Suggest(prefix string, database string) (string, bool) {
+	suggestion := ""
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		entry := h.entries[i]
+		if len(entry) <= len(prefix) || !strings.HasPrefix(entry, prefix) {
+			continue
+		}
+		if h.databases[i] == database {
+			return entry, true
+		}
+		if suggestion == "" {
+			suggestion = entry
+		}
+	}
+	return suggestion, suggestion != ""
+}
+
+// Reset stops navigation through the history.
+func (h *History) Reset() {
+	h.position = len(h.entries)
+	h.line = ""
+}
+
+// encodeEntry escapes the entry in the format of libedit, so each
+// entry is stored on its own line of the history file: backslashes are
+// doubled and whitespace symbols are written as octal codes, e.g.
+// "\040" for the space and "\012" for the new line.
+func encodeEntry(entry string) string {
+	result := make([]byte, 0, len(entry))
+	for i := 0; i < len(entry); i++ {
+		switch c := entry[i]; c {
+		case '\\':
+			result = append(result, '\\', '\\')
+		case ' ', '\t', '\n', '\r', '\v', '\f':
+			result = append(result, '\\', '0'+c>>6, '0'+c>>3&7, '0'+c&7)
+		default:
+			result = append(result, c)
+		}
+	}
+	return string(result)
+}
+
+// decodeEntry is reverse of encodeEntry.
+func decodeEntry(line string) string {
+	if strings.IndexByte(line, '\\') < 0 {
+		return line
+	}
+
+	result := make([]byte, 0, len(line))
+	for i := 0; i < len(line); i++ {
+		if line[i] != '\\' || i+1 == len(line) {
+			result = append(result, line[i])
+			continue
+		}
+		i++
+		switch {
+		case isOctal(line[i]) && i+2 < len(line) && isOctal(line[i+1]) && isOctal(line[i+2]):
+			result = append(result, (line[i]-'0')<<6|(line[i+1]-'0')<<3|(line[i+2]-'0'))
+			i += 2
+		default:
+			result = append(result, line[i])
+		}
+	}
+	return string(result)
+}
+
+// isOctal returns true if the symbol is an octal digit.
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// useDatabase returns the database of the USE statement. It returns
+// false if the entry is not such statement.
+func useDatabase(entry string) (string, bool) {
+	fields := strings.Fields(strings.TrimRight(entry, "; \t\n"))
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "use") {
+		return "", false
+	}
+	return strings.Trim(fields[1], "`"), true
+}
+
+// matchPattern matches the text against the pattern with "*" and "?"
+// wildcards.
+func matchPattern(pattern string, text string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(text); i >= 0; i-- {
+				if matchPattern(pattern[1:], text[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(text) == 0 {
+				return false
+			}
+		default:
+			if len(text) == 0 || text[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		text = text[1:]
+	}
+	return len(text) == 0
+}
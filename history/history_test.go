@@ -0,0 +1,245 @@
+// history package provides persistent history of statements entered
+// in the mysql-cli.
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func tempHistoryFile(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, DefaultFile), func() { os.RemoveAll(dir) }
+}
+
+func TestFilePath(t *testing.T) {
+	os.Setenv("HOME", "/home/user")
+	os.Setenv(FileEnv, "")
+	if FilePath("") != "/home/user/.mysql_history" {
+		t.Error("FilePath failed (default)")
+	}
+
+	os.Setenv(FileEnv, "/tmp/history")
+	defer os.Setenv(FileEnv, "")
+	if FilePath("") != "/tmp/history" {
+		t.Error("FilePath failed (MYSQL_HISTFILE)")
+	}
+	if FilePath("/tmp/other") != "/tmp/other" {
+		t.Error("FilePath failed (--history)")
+	}
+	if FilePath("/dev/null") != "" {
+		t.Error("FilePath failed (/dev/null)")
+	}
+}
+
+func TestAddAndLoad(t *testing.T) {
+	file, cleanup := tempHistoryFile(t)
+	defer cleanup()
+
+	h := New(file, "")
+	for _, entry := range []string{
+		"SELECT 1;",
+		"SELECT 1;",
+		"  ",
+		"SELECT 'a\\nb'\nFROM t;",
+		"SELECT 1;",
+		"CREATE USER u IDENTIFIED BY 'secret';",
+	} {
+		err := h.Add(entry)
+		if err != nil {
+			t.Fatal("Add failed", err)
+		}
+	}
+
+	expected := []string{"SELECT 1;", "SELECT 'a\\nb'\nFROM t;", "SELECT 1;"}
+	loaded := New(file, "")
+	err := loaded.Load()
+	if err != nil {
+		t.Fatal("Load failed", err)
+	}
+	for _, history := range []*History{h, loaded} {
+		entries := history.Entries()
+		if len(entries) != len(expected) {
+			t.Fatal("Add failed (number of entries)", entries)
+		}
+		for i := range expected {
+			if entries[i] != expected[i] {
+				t.Error("Add failed", entries[i])
+			}
+		}
+	}
+
+	err = New(filepath.Join(file, "missing"), "").Load()
+	if err == nil {
+		t.Error("Load must fail if the file is not readable")
+	}
+	err = New(file+".missing", "").Load()
+	if err != nil {
+		t.Error("Load failed (missing file)")
+	}
+}
+
+func TestLibeditFormat(t *testing.T) {
+	file, cleanup := tempHistoryFile(t)
+	defer cleanup()
+
+	h := New(file, "")
+	err := h.Add("SELECT 'a\\b'\nFROM t;")
+	if err != nil {
+		t.Fatal("Add failed", err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "_HiStOrY_V2_\nSELECT\\040'a\\\\b'\\012FROM\\040t;\n" {
+		t.Error("Add failed (libedit format)", string(data))
+	}
+
+	// files of the mysql client
+	err = ioutil.WriteFile(file, []byte("_HiStOrY_V2_\nshow\\040tables;\nSELECT\\0401\\012,\\0402;\n\\134\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = New(file, "")
+	err = h.Load()
+	if err != nil {
+		t.Fatal("Load failed", err)
+	}
+	expected := []string{"show tables;", "SELECT 1\n, 2;", "\\"}
+	entries := h.Entries()
+	if len(entries) != len(expected) {
+		t.Fatal("Load failed (number of entries)", entries)
+	}
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Error("Load failed", entries[i])
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	h := New("", "select *:show?")
+	for entry, expected := range map[string]bool{
+		"SELECT 1":                         true,
+		"select\n1":                        false,
+		"SHOWS":                            true,
+		"SHOW TABLES":                      false,
+		"SET PASSWORD = 'abc'":             true,
+		"alter user u identified by 'abc'": true,
+		"INSERT INTO t VALUES (1)":         false,
+	} {
+		if h.Ignored(entry) != expected {
+			t.Error("Ignored failed", entry)
+		}
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	h := New("", "")
+	h.Add("SELECT 1;")
+	h.Add("SELECT 2;")
+
+	if _, ok := h.Next(); ok {
+		t.Error("Next must fail without navigation")
+	}
+	entry, _ := h.Previous("SEL")
+	if entry != "SELECT 2;" {
+		t.Error("Previous failed", entry)
+	}
+	entry, _ = h.Previous("SELECT 2;")
+	if entry != "SELECT 1;" {
+		t.Error("Previous failed", entry)
+	}
+	if _, ok := h.Previous("SELECT 1;"); ok {
+		t.Error("Previous must fail on the oldest entry")
+	}
+	entry, _ = h.Next()
+	if entry != "SELECT 2;" {
+		t.Error("Next failed", entry)
+	}
+	entry, ok := h.Next()
+	if entry != "SEL" || !ok {
+		t.Error("Next failed (edited line)", entry)
+	}
+	if _, ok := h.Next(); ok {
+		t.Error("Next must fail after the edited line")
+	}
+
+	h.Previous("")
+	h.Reset()
+	entry, _ = h.Previous("")
+	if entry != "SELECT 2;" {
+		t.Error("Reset failed", entry)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	h := New("", "")
+	h.Add("SELECT * FROM users;")
+	h.Add("SHOW TABLES;")
+	h.Add("SELECT id FROM users;")
+
+	index, ok := h.Search("users", 2, true)
+	if !ok || index != 2 {
+		t.Error("Search failed", index)
+	}
+	index, ok = h.Search("users", index-1, true)
+	if !ok || index != 0 {
+		t.Error("Search failed (older entry)", index)
+	}
+	if _, ok = h.Search("users", index-1, true); ok {
+		t.Error("Search must fail after the oldest entry")
+	}
+	index, ok = h.Search("FROM", 1, false)
+	if !ok || index != 2 {
+		t.Error("Search failed (forward)", index)
+	}
+	if _, ok = h.Search("DELETE", 2, true); ok {
+		t.Error("Search must fail without matches")
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	file, remove := tempHistoryFile(t)
+	defer remove()
+
+	ioutil.WriteFile(file, []byte("SELECT * FROM users;\nUSE `shop`;\nSELECT * FROM orders;\n"), 0600)
+	h := New(file, "")
+	err := h.Load()
+	if err != nil {
+		t.Fatal("Load failed", err)
+	}
+	h.SetDatabase("test")
+	h.Add("SELECT * FROM items;")
+
+	tests := []struct {
+		prefix   string
+		database string
+		expected string
+	}{
+		{"SELECT", "test", "SELECT * FROM items;"},
+		{"SELECT", "shop", "SELECT * FROM orders;"},
+		{"SELECT", "", "SELECT * FROM users;"},
+		{"SELECT", "other", "SELECT * FROM items;"},
+		{"SELECT * FROM u", "shop", "SELECT * FROM users;"},
+		{"USE", "", "USE `shop`;"},
+	}
+	for _, test := range tests {
+		suggestion, ok := h.Suggest(test.prefix, test.database)
+		if !ok || suggestion != test.expected {
+			t.Error("Suggest failed", test.prefix, test.database, suggestion)
+		}
+	}
+
+	for _, prefix := range []string{"SELECT * FROM items;", "DELETE"} {
+		if _, ok := h.Suggest(prefix, "test"); ok {
+			t.Error("Suggest must fail", prefix)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package inputrc
+
+// errorInputrc is an implementation of error interface which
+// provides error message for the parser of readline init files.
+type errorInputrc struct {
+	errMsg string
+}
+
+func (err errorInputrc) Error() string {
+	return err.errMsg
+}
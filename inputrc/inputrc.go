@@ -0,0 +1,471 @@
+// inputrc package provides parser of readline init files (see the
+// INITIALIZATION FILE section of readline(3)) which configure key
+// bindings of the mysql-cli line editor.
+package inputrc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultFile is name of the readline init file in the home directory
+	DefaultFile = ".inputrc"
+	// SystemFile is read if the init file in the home directory does
+	// not exist
+	SystemFile = "/etc/inputrc"
+	// FileEnv is enviroment variable with path to the readline init file
+	FileEnv = "INPUTRC"
+)
+
+// maxIncludeDepth limits nesting of $include directives
+const maxIncludeDepth = 10
+
+// Binding binds a key sequence to a function or to a macro.
+type Binding struct {
+	// Keys is the key sequence with escapes translated, e.g. "\x18\x05"
+	Keys string
+	// Function is name of the bound function, empty for macros
+	Function string
+	// Macro is text which is inserted by the key sequence
+	Macro string
+}
+
+// Conditions are tested by $if directives.
+type Conditions struct {
+	// Applications are names of the application, e.g. mysql-cli
+	Applications []string
+	// Mode is the editing mode, emacs or vi. It is changed by
+	// `set editing-mode`.
+	Mode string
+	// Term is name of the terminal
+	Term string
+}
+
+// Config is the result of parsing of readline init files.
+type Config struct {
+	// Bindings in the order of the files
+	Bindings []Binding
+	// Variables set by `set name value`, names are in lower case
+	Variables map[string]string
+}
+
+// ifState is state of a $if directive.
+type ifState struct {
+	parent  bool // true if lines before the $if are applied
+	matched bool // true if lines of the current branch are applied
+}
+
+// parser keeps state of parsing of a file and included files.
+type parser struct {
+	config     *Config
+	conditions Conditions
+	states     []ifState
+	depth      int
+}
+
+// FilePath returns path to the readline init file: the file given by
+// INPUTRC, ~/.inputrc or /etc/inputrc if ~/.inputrc does not exist.
+func FilePath() string {
+	if file := os.Getenv(FileEnv); file != "" {
+		return file
+	}
+	file := filepath.Join(os.Getenv("HOME"), DefaultFile)
+	if _, err := os.Stat(file); err != nil {
+		return SystemFile
+	}
+	return file
+}
+
+// NewConfig returns empty configuration.
+func NewConfig() *Config {
+	return &Config{Variables: map[string]string{}}
+}
+
+// ReadFile parses the file and adds its bindings and variables to the
+// configuration. A missing file is not an error.
+func (config *Config) ReadFile(file string, conditions *Conditions) error {
+	p := &parser{config: config, conditions: *conditions}
+	err := p.readFile(file)
+	*conditions = p.conditions
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Parse parses the text of a readline init file and adds its bindings
+// and variables to the configuration. The name of the file is used in
+// error messages and to resolve relative paths of $include.
+func (config *Config) Parse(text string, file string, conditions *Conditions) error {
+	p := &parser{config: config, conditions: *conditions}
+	err := p.parse(text, file)
+	*conditions = p.conditions
+	return err
+}
+
+// readFile parses the file.
+func (p *parser) readFile(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return p.parse(string(data), file)
+}
+
+// parse parses lines of the text. Wrong lines are skipped and the
+// first error is returned.
+func (p *parser) parse(text string, file string) error {
+	var result error
+	states := len(p.states)
+	for i, line := range strings.Split(text, "\n") {
+		err := p.parseLine(strings.TrimSpace(line), file)
+		if err != nil && result == nil {
+			result = &errorInputrc{fmt.Sprintf("%s:%d: %s", file, i+1, err)}
+		}
+	}
+	// $if without $endif ends at the end of the file
+	p.states = p.states[:states]
+	return result
+}
+
+// active returns true if lines are not skipped by $if directives.
+func (p *parser) active() bool {
+	if len(p.states) == 0 {
+		return true
+	}
+	state := p.states[len(p.states)-1]
+	return state.parent && state.matched
+}
+
+// parseLine parses one line of the file.
+func (p *parser) parseLine(line string, file string) error {
+	if line == "" || line[0] == '#' {
+		return nil
+	}
+
+	if line[0] == '$' {
+		return p.parseDirective(line, file)
+	}
+	if !p.active() {
+		return nil
+	}
+
+	if strings.HasPrefix(line, "set") && len(line) > 3 && (line[3] == ' ' || line[3] == '\t') {
+		fields := strings.Fields(line[4:])
+		if len(fields) == 0 {
+			return &errorInputrc{"variable name expected"}
+		}
+		name := strings.ToLower(fields[0])
+		value := ""
+		if len(fields) > 1 {
+			value = fields[1]
+		}
+		p.config.Variables[name] = value
+		if name == "editing-mode" {
+			p.conditions.Mode = strings.ToLower(value)
+		}
+		return nil
+	}
+
+	binding, err := parseBinding(line)
+	if err != nil {
+		return err
+	}
+	p.config.Bindings = append(p.config.Bindings, binding)
+	return nil
+}
+
+// parseDirective parses $if, $else, $endif and $include directives.
+func (p *parser) parseDirective(line string, file string) error {
+	fields := strings.Fields(line)
+	argument := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+	switch fields[0] {
+	case "$if":
+		p.states = append(p.states, ifState{parent: p.active(), matched: p.test(argument)})
+	case "$else":
+		if len(p.states) == 0 {
+			return &errorInputrc{"$else without $if"}
+		}
+		p.states[len(p.states)-1].matched = !p.states[len(p.states)-1].matched
+	case "$endif":
+		if len(p.states) == 0 {
+			return &errorInputrc{"$endif without $if"}
+		}
+		p.states = p.states[:len(p.states)-1]
+	case "$include":
+		if !p.active() {
+			return nil
+		}
+		if p.depth >= maxIncludeDepth {
+			return &errorInputrc{"too many nested $include directives"}
+		}
+		included := argument
+		if strings.HasPrefix(included, "~/") {
+			included = filepath.Join(os.Getenv("HOME"), included[2:])
+		} else if !filepath.IsAbs(included) {
+			included = filepath.Join(filepath.Dir(file), included)
+		}
+		p.depth++
+		err := p.readFile(included)
+		p.depth--
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	default:
+		return &errorInputrc{"unknown directive " + fields[0]}
+	}
+	return nil
+}
+
+// test returns true if the condition of $if is true. The conditions
+// are mode=name, term=name and name of the application. The name of
+// the terminal matches the full name and the part before the first -.
+func (p *parser) test(condition string) bool {
+	if strings.HasPrefix(condition, "mode=") {
+		return strings.EqualFold(condition[5:], p.conditions.Mode)
+	}
+	if strings.HasPrefix(condition, "term=") {
+		term := condition[5:]
+		return term == p.conditions.Term || term == strings.SplitN(p.conditions.Term, "-", 2)[0]
+	}
+	for _, application := range p.conditions.Applications {
+		if strings.EqualFold(condition, application) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBinding parses `"keys": function`, `"keys": "macro"` and
+// `Key-Name: function` lines.
+func parseBinding(line string) (Binding, error) {
+	binding := Binding{}
+	rest := ""
+	if line[0] == '"' {
+		quoted, n, err := scanQuoted(line)
+		if err != nil {
+			return binding, err
+		}
+		binding.Keys, err = TranslateKeys(quoted)
+		if err != nil {
+			return binding, err
+		}
+		rest = strings.TrimSpace(line[n:])
+		if !strings.HasPrefix(rest, ":") {
+			return binding, &errorInputrc{"':' expected after the key sequence"}
+		}
+		rest = rest[1:]
+	} else {
+		colon := strings.IndexByte(line, ':')
+		if colon < 0 {
+			return binding, &errorInputrc{"':' expected after the key name"}
+		}
+		keys, err := keyName(strings.TrimSpace(line[:colon]))
+		if err != nil {
+			return binding, err
+		}
+		binding.Keys = keys
+		rest = line[colon+1:]
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return binding, &errorInputrc{"function name or macro expected"}
+	}
+	if rest[0] == '"' || rest[0] == '\'' {
+		quoted, _, err := scanQuoted(rest)
+		if err != nil {
+			return binding, err
+		}
+		binding.Macro, err = TranslateKeys(quoted)
+		return binding, err
+	}
+	binding.Function = strings.ToLower(strings.Fields(rest)[0])
+	return binding, nil
+}
+
+// scanQuoted returns text between the quotes at the beginning of the
+// line and length of the quoted text with the quotes.
+func scanQuoted(line string) (string, int, error) {
+	quote := line[0]
+	for i := 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case quote:
+			return line[1:i], i + 1, nil
+		}
+	}
+	return "", 0, &errorInputrc{"unterminated key sequence"}
+}
+
+// keyNames are symbolic names of keys which may be used in bindings
+// without quotes.
+var keyNames = map[string]byte{
+	"del":     127,
+	"esc":     27,
+	"escape":  27,
+	"lfd":     '\n',
+	"newline": '\n',
+	"ret":     '\r',
+	"return":  '\r',
+	"rubout":  127,
+	"spc":     ' ',
+	"space":   ' ',
+	"tab":     '\t',
+}
+
+// keyName translates key names like Control-x, Meta-Rubout and C-M-f.
+func keyName(name string) (string, error) {
+	control := false
+	meta := false
+	for {
+		lower := strings.ToLower(name)
+		switch {
+		case strings.HasPrefix(lower, "control-"):
+			control = true
+			name = name[8:]
+			continue
+		case strings.HasPrefix(lower, "c-"):
+			control = true
+			name = name[2:]
+			continue
+		case strings.HasPrefix(lower, "meta-"):
+			meta = true
+			name = name[5:]
+			continue
+		case strings.HasPrefix(lower, "m-"):
+			meta = true
+			name = name[2:]
+			continue
+		}
+		break
+	}
+
+	var key byte
+	if named, ok := keyNames[strings.ToLower(name)]; ok {
+		key = named
+	} else if len(name) == 1 {
+		key = name[0]
+	} else {
+		return "", &errorInputrc{"unknown key name " + name}
+	}
+
+	if control {
+		key = controlKey(key)
+	}
+	if meta {
+		return "\x1b" + string([]byte{key}), nil
+	}
+	return string([]byte{key}), nil
+}
+
+// controlKey returns the control character of the key, Control-? is
+// the Rubout.
+func controlKey(key byte) byte {
+	if key == '?' {
+		return 127
+	}
+	if key >= 'a' && key <= 'z' {
+		key -= 'a' - 'A'
+	}
+	return key & 0x1f
+}
+
+// TranslateKeys translates escape sequences of a quoted key sequence
+// or macro: \C- and \M- prefixes, \e, \\, \", \', \a, \b, \d, \f, \n,
+// \r, \t, \v, octal \nnn and hexadecimal \xHH. Meta characters are
+// translated to the Escape prefix.
+func TranslateKeys(keys string) (string, error) {
+	result := []byte{}
+	control := false
+	meta := false
+	for i := 0; i < len(keys); i++ {
+		var key byte
+		if keys[i] != '\\' || i+1 == len(keys) {
+			key = keys[i]
+		} else {
+			i++
+			switch keys[i] {
+			case 'C', 'M':
+				if i+1 < len(keys) && keys[i+1] == '-' {
+					if keys[i] == 'C' {
+						control = true
+					} else {
+						meta = true
+					}
+					i++
+					if i+1 == len(keys) {
+						return "", &errorInputrc{"key expected after \\" + string(keys[i-1]) + "-"}
+					}
+					continue
+				}
+				key = keys[i]
+			case 'e':
+				key = 27
+			case 'a':
+				key = 7
+			case 'b':
+				key = 8
+			case 'd':
+				key = 127
+			case 'f':
+				key = 12
+			case 'n':
+				key = '\n'
+			case 'r':
+				key = '\r'
+			case 't':
+				key = '\t'
+			case 'v':
+				key = 11
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				n := 1
+				for n < 3 && i+n < len(keys) && keys[i+n] >= '0' && keys[i+n] <= '7' {
+					n++
+				}
+				value, err := strconv.ParseUint(keys[i:i+n], 8, 8)
+				if err != nil {
+					return "", &errorInputrc{"octal escape \\" + keys[i:i+n] + " is out of range"}
+				}
+				key = byte(value)
+				i += n - 1
+			case 'x':
+				n := 0
+				for n < 2 && i+1+n < len(keys) && isHexDigit(keys[i+1+n]) {
+					n++
+				}
+				if n == 0 {
+					key = 'x'
+					break
+				}
+				value, _ := strconv.ParseUint(keys[i+1:i+1+n], 16, 8)
+				key = byte(value)
+				i += n
+			default:
+				// \\, \", \' and unknown escapes are the symbol itself
+				key = keys[i]
+			}
+		}
+
+		if control {
+			key = controlKey(key)
+			control = false
+		}
+		if meta {
+			result = append(result, 27)
+			meta = false
+		}
+		result = append(result, key)
+	}
+	return string(result), nil
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,134 @@
+// inputrc package provides parser of readline init files which
+// configure key bindings of the mysql-cli line editor.
+package inputrc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTranslateKeys(t *testing.T) {
+	tests := map[string]string{
+		"\\C-x\\C-e":   "\x18\x05",
+		"\\C-?":        "\x7f",
+		"\\M-b":        "\x1bb",
+		"\\e[A":        "\x1b[A",
+		"\\M-\\C-j":    "\x1b\x0a",
+		"\\\"\\\\\\'":  "\"\\'",
+		"\\t\\r\\n\\d": "\t\r\n\x7f",
+		"\\033\\x7f":   "\x1b\x7f",
+		"\\101b":       "Ab",
+		"\\3770":       "\xff0",
+		"select 1":     "select 1",
+	}
+	for keys, expected := range tests {
+		result, err := TranslateKeys(keys)
+		if err != nil || result != expected {
+			t.Errorf("TranslateKeys(%q) failed: %q %v", keys, result, err)
+		}
+	}
+
+	_, err := TranslateKeys("\\C-")
+	if err == nil {
+		t.Error("TranslateKeys failed (missing key)")
+	}
+	_, err = TranslateKeys("\\400")
+	if err == nil {
+		t.Error("TranslateKeys failed (octal escape out of range)")
+	}
+}
+
+func TestParse(t *testing.T) {
+	text := `# comment
+"\C-x\C-e": edit-command
+Control-u: Undo
+Meta-Rubout: backward-kill-word
+"\C-xs": "select 1"
+set editing-mode vi
+$if mode=vi
+"\C-l": clear-screen
+$else
+"\C-l": redraw-current-line
+$endif
+$if Bash
+"\C-xb": bash-only
+$endif
+$if mysql-cli
+$if term=xterm
+"\C-xm": yank
+$endif
+$endif
+`
+	config := NewConfig()
+	conditions := &Conditions{Applications: []string{"mysql-cli"}, Mode: "emacs", Term: "xterm-256color"}
+	err := config.Parse(text, "inputrc", conditions)
+	if err != nil {
+		t.Fatal("Parse failed", err)
+	}
+
+	expected := []Binding{
+		{"\x18\x05", "edit-command", ""},
+		{"\x15", "undo", ""},
+		{"\x1b\x7f", "backward-kill-word", ""},
+		{"\x18s", "", "select 1"},
+		{"\x0c", "clear-screen", ""},
+		{"\x18m", "yank", ""},
+	}
+	if len(config.Bindings) != len(expected) {
+		t.Fatal("Parse failed (number of bindings)", config.Bindings)
+	}
+	for i := range expected {
+		if config.Bindings[i] != expected[i] {
+			t.Error("Parse failed", config.Bindings[i], expected[i])
+		}
+	}
+	if config.Variables["editing-mode"] != "vi" || conditions.Mode != "vi" {
+		t.Error("Parse failed (editing-mode)")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	config := NewConfig()
+	err := config.Parse("\"\\C-a\": beginning-of-line\n\"\\C-b\n\"\\C-e\": end-of-line\n", "keys", &Conditions{})
+	if err == nil || err.Error() != "keys:2: unterminated key sequence" {
+		t.Error("Parse failed (error)", err)
+	}
+	// wrong lines are skipped
+	if len(config.Bindings) != 2 {
+		t.Error("Parse failed (bindings after error)", config.Bindings)
+	}
+
+	for _, line := range []string{"$endif", "Hyper-x: undo", "\"\\C-a\" undo", "\"\\C-a\":"} {
+		err := NewConfig().Parse(line, "keys", &Conditions{})
+		if err == nil {
+			t.Error("Parse failed (no error)", line)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inputrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "included"), []byte("\"\\C-xu\": undo\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "inputrc"), []byte("$include included\n\"\\C-xr\": redo\n"), 0644)
+
+	config := NewConfig()
+	err = config.ReadFile(filepath.Join(dir, "inputrc"), &Conditions{})
+	if err != nil {
+		t.Fatal("ReadFile failed", err)
+	}
+	if len(config.Bindings) != 2 || config.Bindings[0].Function != "undo" || config.Bindings[1].Function != "redo" {
+		t.Error("ReadFile failed ($include)", config.Bindings)
+	}
+
+	err = config.ReadFile(filepath.Join(dir, "missing"), &Conditions{})
+	if err != nil {
+		t.Error("ReadFile failed (missing file)", err)
+	}
+}
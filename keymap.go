@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/0xAX/mysql-tools/inputrc"
+	"github.com/0xAX/mysql-tools/terminfo"
+)
+
+// keymapFile is the file with key bindings of mysql-cli in the home
+// directory. It has the syntax of readline init files and is read
+// after ~/.inputrc.
+const keymapFile = ".mysql-cli/keys"
+
+// defaultBindings are key bindings of the emacs editing mode.
+const defaultBindings = `
+"\C-a": beginning-of-line
+"\C-b": backward-char
+"\C-c": quit
+"\C-d": end-of-file
+"\C-e": end-of-line
+"\C-f": forward-char
+"\C-h": backward-delete-char
+"\C-k": kill-line
+"\C-l": clear-screen
+"\C-m": accept-line
+"\C-n": next-history
+"\C-p": previous-history
+"\C-r": reverse-search-history
+"\C-s": forward-search-history
+"\C-t": transpose-chars
+"\C-u": unix-line-discard
+"\C-w": unix-word-rubout
+"\C-y": yank
+"\C-_": undo
+"\C-x\C-e": edit-command
+"\C-x\C-u": undo
+"\C-^": redo
+"\C-?": backward-delete-char
+"\eb": backward-word
+"\ed": kill-word
+"\ef": forward-word
+"\ey": yank-pop
+"\e[200~": bracketed-paste-begin
+`
+
+// defaultKeyNames are bindings of special keys by their names (see
+// terminfo.Key). They are used if the sequence of the key is not bound.
+var defaultKeyNames = map[string]string{
+	"Up":         "previous-history",
+	"Down":       "next-history",
+	"Left":       "backward-char",
+	"Right":      "forward-char",
+	"Home":       "beginning-of-line",
+	"End":        "end-of-line",
+	"Delete":     "delete-char",
+	"Enter":      "accept-line",
+	"Ctrl-Left":  "backward-word",
+	"Ctrl-Right": "forward-word",
+	"Alt-Left":   "backward-word",
+	"Alt-Right":  "forward-word",
+}
+
+// keyFunction is a named command of the line editor which may be
+// bound to keys. It returns false if mysql-cli must be stopped.
+type keyFunction func(t *Terminal, cmd *SqlCommandBuffer) bool
+
+// command returns key function which runs the editing command.
+func command(f func(t *Terminal, cmd *SqlCommandBuffer)) keyFunction {
+	return func(t *Terminal, cmd *SqlCommandBuffer) bool {
+		f(t, cmd)
+		return true
+	}
+}
+
+// killCommand returns key function which saves the text removed by
+// the editing command to the kill ring.
+func killCommand(f func(t *Terminal, cmd *SqlCommandBuffer) string, backward bool) keyFunction {
+	return func(t *Terminal, cmd *SqlCommandBuffer) bool {
+		t.KillRing.Kill(f(t, cmd), backward)
+		return true
+	}
+}
+
+// keyFunctions are functions which may be bound to keys by name. The
+// names are the names of the same readline functions.
+var keyFunctions = map[string]keyFunction{
+	"accept-line": func(t *Terminal, cmd *SqlCommandBuffer) bool {
+		return t.enter(cmd, t.splitter)
+	},
+	"backward-char":         command(moveLeft),
+	"backward-delete-char":  command(backspace),
+	"backward-word":         command(backwardWord),
+	"beginning-of-line":     command(beginningOfLine),
+	"bracketed-paste-begin": command(paste),
+	"clear-screen":          command(clearScreen),
+	"delete-char":           command(deleteChar),
+	"edit-command":          command(editBuffer),
+	"emacs-editing-mode": command(func(t *Terminal, cmd *SqlCommandBuffer) {
+		t.Vi = nil
+		refreshLine(t, cmd)
+	}),
+	"end-of-file": func(t *Terminal, cmd *SqlCommandBuffer) bool {
+		// end of input on the empty line
+		if cmd.Line() == "" {
+			return false
+		}
+		deleteChar(t, cmd)
+		return true
+	},
+	"end-of-line":            command(endOfLine),
+	"forward-char":           command(moveRight),
+	"forward-search-history": searchCommand(false),
+	"forward-word":           command(forwardWord),
+	"kill-line":              killCommand(killLine, false),
+	"kill-word":              killCommand(killWord, false),
+	"next-history":           command(historyNext),
+	"previous-history":       command(historyPrevious),
+	"quit": func(t *Terminal, cmd *SqlCommandBuffer) bool {
+		return false
+	},
+	"redo":                   command(redo),
+	"reverse-search-history": searchCommand(true),
+	"transpose-chars":        command(transposeChars),
+	"undo":                   command(undo),
+	"unix-line-discard":      killCommand(unixLineDiscard, true),
+	"unix-word-rubout":       killCommand(unixWordRubout, true),
+	"vi-editing-mode": command(func(t *Terminal, cmd *SqlCommandBuffer) {
+		if t.Vi == nil {
+			t.Vi = NewViMode()
+		}
+		refreshLine(t, cmd)
+	}),
+	"yank":     command(yank),
+	"yank-pop": command(yankPop),
+}
+
+// searchCommand returns key function of the incremental search which
+// executes the buffer if the search is finished by Enter.
+func searchCommand(backward bool) keyFunction {
+	return func(t *Terminal, cmd *SqlCommandBuffer) bool {
+		if reverseSearch(t, cmd, backward) {
+			return t.enter(cmd, t.splitter)
+		}
+		return true
+	}
+}
+
+// keyBinding is a function or a macro bound to a key sequence.
+type keyBinding struct {
+	function keyFunction
+	macro    string
+}
+
+// Keymap maps key sequences to functions of the line editor. The
+// bindings are used in the emacs editing mode and for the keys which
+// are not processed by the vi editing mode.
+type Keymap struct {
+	bindings map[string]keyBinding
+	// names are bindings of special keys by their names
+	names map[string]keyBinding
+	// prefixes are proper prefixes of the bound key sequences
+	prefixes map[string]bool
+}
+
+// NewKeymap returns keymap with the default bindings.
+func NewKeymap() *Keymap {
+	k := &Keymap{bindings: map[string]keyBinding{}, names: map[string]keyBinding{}, prefixes: map[string]bool{}}
+	config := inputrc.NewConfig()
+	config.Parse(defaultBindings, "default", &inputrc.Conditions{})
+	k.Apply(config, true)
+	for name, function := range defaultKeyNames {
+		k.names[name] = keyBinding{function: keyFunctions[function]}
+	}
+	return k
+}
+
+// Bind binds the key sequence to the function with the given name.
+func (k *Keymap) Bind(keys string, name string) error {
+	function, ok := keyFunctions[name]
+	if !ok {
+		return fmt.Errorf("Unknown function %s", name)
+	}
+	k.bind(keys, keyBinding{function: function})
+	return nil
+}
+
+// BindMacro binds the key sequence to the text which is inserted.
+func (k *Keymap) BindMacro(keys string, macro string) {
+	k.bind(keys, keyBinding{macro: macro})
+}
+
+func (k *Keymap) bind(keys string, binding keyBinding) {
+	if keys == "" {
+		return
+	}
+	k.bindings[keys] = binding
+	for i := 1; i < len(keys); i++ {
+		k.prefixes[keys[:i]] = true
+	}
+}
+
+// Apply adds bindings of the parsed init file to the keymap. The
+// first binding with unknown function is returned as an error if
+// strict is true, otherwise such bindings are ignored as functions of
+// other readline applications.
+func (k *Keymap) Apply(config *inputrc.Config, strict bool) error {
+	var result error
+	for _, binding := range config.Bindings {
+		if binding.Function == "" {
+			k.BindMacro(binding.Keys, binding.Macro)
+			continue
+		}
+		err := k.Bind(binding.Keys, binding.Function)
+		if err != nil && strict && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// read reads the rest of the bound key sequence which starts with the
+// given key. It returns the read keys and the binding if they are
+// bound. Keys which are not bound by their sequence are looked up by
+// their names. If the keys are bound and are a prefix of other
+// sequences, the next key is awaited for a short time like after
+// Escape. A lone Escape is decoded after such timeout already, so it
+// is not merged with the next key even if it is a prefix of bindings
+// like "\eb".
+func (k *Keymap) read(input *terminfo.KeyDecoder, key terminfo.Key) (string, keyBinding, bool) {
+	keys := key.Sequence
+	for {
+		binding, bound := k.bindings[keys]
+		if !bound && keys == key.Sequence && key.Name != "" {
+			binding, bound = k.names[key.String()]
+		}
+		if !k.prefixes[keys] || key.Name == "Escape" || bound && !input.Wait(input.Timeout) {
+			return keys, binding, bound
+		}
+		next, err := input.ReadKey()
+		if err != nil {
+			return keys, binding, bound
+		}
+		keys += next.Sequence
+	}
+}
+
+// loadKeymap reads key bindings from ~/.inputrc (or the file given
+// by INPUTRC) and from ~/.mysql-cli/keys. The editing mode is selected
+// by `set editing-mode`, --vi overrides it.
+func loadKeymap(t *Terminal) {
+	mode := "emacs"
+	if *vi {
+		mode = "vi"
+	}
+	conditions := &inputrc.Conditions{
+		Applications: []string{"mysql-cli", "mysql"},
+		Mode:         mode,
+		Term:         os.Getenv("TERM"),
+	}
+
+	// functions of other readline applications in ~/.inputrc are ignored
+	readKeymapFile(t.Keymap, inputrc.FilePath(), conditions, false)
+	readKeymapFile(t.Keymap, filepath.Join(os.Getenv("HOME"), keymapFile), conditions, true)
+
+	if *vi || conditions.Mode == "vi" {
+		t.Vi = NewViMode()
+	}
+}
+
+// readKeymapFile adds bindings of the file to the keymap. Errors are
+// reported as warnings and the correct bindings are used.
+func readKeymapFile(k *Keymap, file string, conditions *inputrc.Conditions, strict bool) {
+	config := inputrc.NewConfig()
+	err := config.ReadFile(file, conditions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mysql-cli: [Warning] %s\n", err)
+	}
+	err = k.Apply(config, strict)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mysql-cli: [Warning] %s: %s\n", file, err)
+	}
+}
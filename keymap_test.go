@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/0xAX/mysql-tools/inputrc"
+	"github.com/0xAX/mysql-tools/terminfo"
+)
+
+func TestKeymapRead(t *testing.T) {
+	tests := []struct {
+		input string
+		read  string
+		bound bool
+		rest  string
+	}{
+		{"\x01x", "\x01", true, "x"},
+		{"ax", "a", false, "x"},
+		// the rest of bound sequences is read
+		{"\x1bb", "\x1bb", true, ""},
+		{"\x1b[200~SELECT", "\x1b[200~", true, "SELECT"},
+		{"\x18\x15", "\x18\x15", true, ""},
+		{"\x18x", "\x18x", false, ""},
+		// special keys are bound by their names
+		{"\x1b[Ax", "\x1b[A", true, "x"},
+		{"\x1bOAx", "\x1bOA", true, "x"},
+		{"\x1b[3~x", "\x1b[3~", true, "x"},
+		{"\x1b[1;5Cx", "\x1b[1;5C", true, "x"},
+		{"\x1bOPx", "\x1bOP", false, "x"},
+		// the macro is bound to the sequence with the bound prefix
+		{"\x18s", "\x18s", true, ""},
+		{"\x18sx", "\x18s", true, "x"},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, test.input)
+		keymap := NewKeymap()
+		keymap.BindMacro("\x18s", "SELECT ")
+		key, err := terminal.Input.ReadKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		read, _, bound := keymap.read(terminal.Input, key)
+		rest := unreadInput(terminal)
+		restore()
+		if read != test.read || bound != test.bound || rest != test.rest {
+			t.Errorf("read of %q: %q %v and %q is left, expected %q %v and %q", test.input,
+				read, bound, rest, test.read, test.bound, test.rest)
+		}
+	}
+}
+
+func TestKeymapReadEscape(t *testing.T) {
+	terminal, restore := testTerminal(t, "d")
+	defer restore()
+	// Escape is decoded after the timeout, so it is not merged with
+	// the next key to Alt-D
+	escape := terminfo.Key{Name: "Escape", Sequence: "\x1b"}
+	read, _, _ := NewKeymap().read(terminal.Input, escape)
+	if rest := unreadInput(terminal); read != "\x1b" || rest != "d" {
+		t.Errorf("read of Escape: %q and %q is left", read, rest)
+	}
+}
+
+func TestKeymapBind(t *testing.T) {
+	keymap := NewKeymap()
+	if err := keymap.Bind("\x18x", "no-such-function"); err == nil {
+		t.Error("Bind of unknown function must fail")
+	}
+	if err := keymap.Bind("\x18x", "kill-line"); err != nil {
+		t.Error("Bind failed", err)
+	}
+	if _, ok := keymap.bindings["\x18x"]; !ok || !keymap.prefixes["\x18"] {
+		t.Error("Bind failed")
+	}
+
+	config := inputrc.NewConfig()
+	err := config.Parse(`"\C-xa": unknown-function
+"\C-xb": "SELECT 1;"
+"\C-xc": yank`, "test", &inputrc.Conditions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// unknown functions are errors only in strict mode
+	if err := keymap.Apply(config, false); err != nil {
+		t.Error("Apply failed", err)
+	}
+	if err := keymap.Apply(config, true); err == nil {
+		t.Error("Apply of unknown function must fail in strict mode")
+	}
+	if binding := keymap.bindings["\x18b"]; binding.function != nil || binding.macro != "SELECT 1;" {
+		t.Error("Apply failed (macro)")
+	}
+	if binding := keymap.bindings["\x18c"]; binding.function == nil {
+		t.Error("Apply failed (function)")
+	}
+	if _, ok := keymap.bindings["\x18a"]; ok {
+		t.Error("Apply bound unknown function")
+	}
+}
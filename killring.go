@@ -0,0 +1,91 @@
+package main
+
+// killRingSize is maximum number of entries of the kill ring
+const killRingSize = 10
+
+// KillRing keeps the text removed by the kill commands (Ctrl-K,
+// Ctrl-U, Ctrl-W, Alt-D), so it can be inserted back with Ctrl-Y
+// and Alt-Y.
+type KillRing struct {
+	// killed text from the oldest to the newest one
+	entries []string
+	// index of the last yanked entry
+	index int
+	// start and end of the last yanked text in the buffer
+	yankStart uint64
+	yankEnd   uint64
+	// the current command is a kill or a yank
+	killed bool
+	yanked bool
+	// the previous command is a kill or a yank
+	wasKilled bool
+	wasYanked bool
+}
+
+// NewKillRing returns empty kill ring.
+func NewKillRing() *KillRing {
+	return &KillRing{}
+}
+
+// Begin must be called before each editing command. Consecutive
+// kills are merged to one entry and Alt-Y works only after a yank.
+func (r *KillRing) Begin() {
+	r.wasKilled, r.wasYanked = r.killed, r.yanked
+	r.killed, r.yanked = false, false
+}
+
+// Kill adds the killed text to the ring. If the previous command is
+// a kill too, the text is appended (or prepended for backward kills)
+// to the last entry like readline does it.
+func (r *KillRing) Kill(text string, backward bool) {
+	r.killed = true
+	if text == "" {
+		r.killed = r.wasKilled
+		return
+	}
+	if r.wasKilled && len(r.entries) > 0 {
+		last := len(r.entries) - 1
+		if backward {
+			r.entries[last] = text + r.entries[last]
+		} else {
+			r.entries[last] += text
+		}
+		return
+	}
+	r.entries = append(r.entries, text)
+	if len(r.entries) > killRingSize {
+		r.entries = r.entries[1:]
+	}
+}
+
+// yank inserts the newest killed text at the cursor (Ctrl-Y).
+func yank(t *Terminal, cmd *SqlCommandBuffer) {
+	r := t.KillRing
+	if len(r.entries) == 0 {
+		return
+	}
+	r.index = len(r.entries) - 1
+	yankEntry(t, cmd)
+}
+
+// yankPop replaces the text inserted by the previous yank with the
+// older killed text (Alt-Y).
+func yankPop(t *Terminal, cmd *SqlCommandBuffer) {
+	r := t.KillRing
+	if !r.wasYanked || len(r.entries) == 0 {
+		return
+	}
+	cmd.Remove(r.yankStart, r.yankEnd)
+	r.index = (r.index - 1 + len(r.entries)) % len(r.entries)
+	yankEntry(t, cmd)
+}
+
+// yankEntry inserts the current entry of the ring at the cursor.
+func yankEntry(t *Terminal, cmd *SqlCommandBuffer) {
+	r := t.KillRing
+	r.yankStart = cmd.Position
+	cmd.Insert([]byte(r.entries[r.index]))
+	r.yankEnd = cmd.Position
+	r.yanked = true
+	refreshLine(t, cmd)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// kill is the killed text of one command.
+type kill struct {
+	text     string
+	backward bool
+}
+
+func TestKill(t *testing.T) {
+	tests := []struct {
+		kills   [][]kill
+		entries []string
+	}{
+		// consecutive kills are merged
+		{[][]kill{{{"abc", false}, {" def", false}}}, []string{"abc def"}},
+		{[][]kill{{{"def", true}, {"abc ", true}}}, []string{"abc def"}},
+		{[][]kill{{{"def", false}, {"abc ", true}}}, []string{"abc def"}},
+		// other commands break the sequence of kills
+		{[][]kill{{{"abc", false}}, {{"def", false}}}, []string{"abc", "def"}},
+		// empty kills do not break the sequence of kills
+		{[][]kill{{{"def", true}, {"", true}, {"abc ", true}}}, []string{"abc def"}},
+		{[][]kill{{{"", true}}}, nil},
+	}
+	for _, test := range tests {
+		ring := NewKillRing()
+		for _, kills := range test.kills {
+			for _, kill := range kills {
+				ring.Begin()
+				ring.Kill(kill.text, kill.backward)
+			}
+			// other command
+			ring.Begin()
+		}
+		if !reflect.DeepEqual(ring.entries, test.entries) {
+			t.Errorf("%v: entries %q, expected %q", test.kills, ring.entries, test.entries)
+		}
+	}
+}
+
+func TestKillRingSize(t *testing.T) {
+	ring := NewKillRing()
+	for i := 0; i < killRingSize+2; i++ {
+		ring.Begin()
+		ring.Kill(string(rune('a'+i)), false)
+		ring.Begin()
+	}
+	if len(ring.entries) != killRingSize || ring.entries[0] != "c" {
+		t.Errorf("entries %q", ring.entries)
+	}
+}
+
+func TestYank(t *testing.T) {
+	tests := []struct {
+		commands string
+		text     string
+	}{
+		// yank inserts the last entry
+		{"y", "[three]"},
+		{"yy", "[threethree]"},
+		// yank-pop rotates the entries
+		{"yp", "[two]"},
+		{"ypp", "[one]"},
+		{"yppp", "[three]"},
+		// yank-pop works after yank only
+		{"p", "[]"},
+		{"yxp", "[threex]"},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, "")
+		for _, text := range []string{"one", "two", "three"} {
+			terminal.KillRing.Begin()
+			terminal.KillRing.Kill(text, false)
+			terminal.KillRing.Begin()
+		}
+		cmd := testBuffer("[]", 1)
+		for _, command := range test.commands {
+			terminal.KillRing.Begin()
+			switch command {
+			case 'y':
+				yank(terminal, cmd)
+			case 'p':
+				yankPop(terminal, cmd)
+			default:
+				insert(terminal, cmd, []byte{byte(command)})
+			}
+		}
+		restore()
+		if cmd.String() != test.text {
+			t.Errorf("%q: text %q, expected %q", test.commands, cmd.String(), test.text)
+		}
+	}
+}
@@ -1,20 +1,195 @@
 package main
 
-import "flag"
+import (
+	"fmt"
+	"os"
+
+	"github.com/0xAX/mysql-tools/history"
+	"github.com/0xAX/mysql-tools/mysql"
+	"github.com/0xAX/mysql-tools/options"
+)
+
+// Version of mysql-cli
+const Version = "0.1.0"
 
 func main() {
 	/* parse mysql-cli flags (defined in flags.go) */
-	flag.Parse()
+	err := commandLine.Parse(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mysql-cli: [ERROR] %s.\n", err)
+		os.Exit(1)
+	}
+
+	if *version {
+		printVersion()
+		return
+	}
+	if *help {
+		printVersion()
+		fmt.Println("Usage: mysql-cli [OPTIONS] [database]")
+		commandLine.PrintUsage(os.Stdout)
+		return
+	}
 
-	/* TODO connect to database */
-	/* TODO parse configuration */
+	/* parse configuration */
+	err = readDefaults()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mysql-cli: [ERROR] %s.\n", err)
+		os.Exit(1)
+	}
+	/* options given on the command line override option files */
+	err = commandLine.Parse(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mysql-cli: [ERROR] %s.\n", err)
+		os.Exit(1)
+	}
+
+	/* the first non-option argument is the database name */
+	args := commandLine.Args()
+	if len(args) > 0 {
+		*db = args[0]
+	}
+
+	/* connect to database */
+	if !commandLine.IsSet("password") && os.Getenv(passwordEnv) != "" {
+		fmt.Fprintf(os.Stderr, "mysql-cli: [Warning] Using a password via %s is insecure.\n", passwordEnv)
+		password.Value = os.Getenv(passwordEnv)
+	}
+	if password.NoValue {
+		password.Value, err = readPassword()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	sslMode, err := sslModeFromFlags()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	network, address, err := mysql.ResolveAddress(*host, *port, *socket, *protocol)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	conn, err := mysql.Connect(&mysql.Config{
+		User:        *user,
+		Password:    password.Value,
+		Database:    *db,
+		Network:     network,
+		Address:     address,
+		BindAddress: *bind_address,
+
+		ServerPublicKeyPath: *server_public_key_path,
+		GetServerPublicKey:  *get_server_public_key,
+
+		SSLMode:    sslMode,
+		SSLCA:      *ssl_ca,
+		SSLCert:    *ssl_cert,
+		SSLKey:     *ssl_key,
+		TLSVersion: *tls_version,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	err = LoadDatabases(conn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
 
 	/* initialize terminal and collect information about current terminal session  */
 	terminal, err := InitTerm()
 	if err != nil {
 		panic(err)
 	}
+	terminal.Conn = conn
+	terminal.History = loadHistory()
+	loadKeymap(terminal)
+	terminal.AutoPairs = *auto_pairs
+	terminal.Suggestions = !*no_suggestions
+	if !*no_color {
+		terminal.Highlighter, err = NewHighlighter(terminal.TermInfo, *color_theme)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mysql-cli: [Warning] %s.\n", err)
+		}
+	}
 
 	/* start main loop */
 	terminal.IoLoop()
 }
+
+// sslModeFromFlags returns SSL mode selected by --ssl-mode. If the
+// mode is not given, but --ssl-ca is, the server certificate is
+// verified like mysql does it.
+func sslModeFromFlags() (mysql.SSLMode, error) {
+	if *ssl_mode == "" {
+		if *ssl_ca != "" {
+			return mysql.SSL_MODE_VERIFY_CA, nil
+		}
+		return mysql.SSL_MODE_PREFERRED, nil
+	}
+	return mysql.ParseSSLMode(*ssl_mode)
+}
+
+// readDefaults reads options from the option files (my.cnf) and from
+// the login path file (.mylogin.cnf). The files given by --defaults-file
+// and --defaults-extra-file must exist. The login path file is read
+// even with --no-defaults like mysql does it.
+func readDefaults() error {
+	groups := optionGroups
+	if *login_path != "" {
+		groups = append(groups, *login_path)
+	}
+	groups = options.DefaultsGroups(groups, *defaults_group_suffix)
+
+	if !*no_defaults {
+		for _, file := range []string{*defaults_file, *defaults_extra_file} {
+			if file == "" {
+				continue
+			}
+			_, err := os.Stat(file)
+			if err != nil {
+				return fmt.Errorf("Could not open required defaults file: %s", file)
+			}
+		}
+
+		files := options.DefaultsFiles(*defaults_file, *defaults_extra_file)
+		err := commandLine.ReadDefaults(files, groups)
+		if err != nil {
+			return err
+		}
+	}
+
+	loginPath := *login_path
+	if loginPath == "" {
+		loginPath = "client"
+	}
+	loginGroups := options.DefaultsGroups([]string{"client", "mysql", loginPath}, *defaults_group_suffix)
+	return commandLine.ReadLoginPath(options.LoginPathFile(), loginGroups)
+}
+
+// loadHistory reads the history file selected by --history or by
+// MYSQL_HISTFILE.
+func loadHistory() *history.History {
+	ignore := *histignore
+	if ignore == "" {
+		ignore = os.Getenv(history.IgnoreEnv)
+	}
+	h := history.New(history.FilePath(*history_file), ignore)
+	err := h.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mysql-cli: [Warning] Failed to read history file: %s\n", err)
+	}
+	return h
+}
+
+// printVersion prints version of mysql-cli.
+func printVersion() {
+	fmt.Printf("mysql-cli  Ver %s\n", Version)
+}
@@ -0,0 +1,90 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol.
+package mysql
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// enviroment variables which provide default address of the server
+const (
+	hostEnv     = "MYSQL_HOST"
+	tcpPortEnv  = "MYSQL_TCP_PORT"
+	unixPortEnv = "MYSQL_UNIX_PORT"
+)
+
+// default paths to the Unix socket of the server
+var defaultSockets = []string{
+	"/var/run/mysqld/mysqld.sock",
+	"/var/lib/mysql/mysql.sock",
+	"/tmp/mysql.sock",
+}
+
+// ResolveAddress returns network and address of the server which
+// should be passed to the Config. It follows the rules of the mysql
+// client:
+//
+//   - the empty host means localhost (or $MYSQL_HOST if it is set);
+//   - localhost means connection via the Unix socket, other hosts
+//     (including 127.0.0.1) mean connection via TCP;
+//   - protocol (TCP or SOCKET) overrides the rules above.
+//
+// The port 0 means the default port ($MYSQL_TCP_PORT or 3306) and the
+// empty socket means the default Unix socket.
+func ResolveAddress(host string, port int, socket string, protocol string) (string, string, error) {
+	if host == "" {
+		host = os.Getenv(hostEnv)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+
+	network := "tcp"
+	switch strings.ToUpper(protocol) {
+	case "":
+		if host == "localhost" {
+			network = "unix"
+		}
+	case "TCP":
+		network = "tcp"
+	case "SOCKET":
+		network = "unix"
+	case "PIPE", "MEMORY":
+		return "", "", &errorMysql{"protocol " + protocol + " is not supported on this platform"}
+	default:
+		return "", "", &errorMysql{"unknown protocol '" + protocol + "'"}
+	}
+
+	if network == "unix" {
+		if socket == "" {
+			socket = DefaultSocket()
+		}
+		return network, socket, nil
+	}
+
+	if port == 0 {
+		port = DefaultPort
+		if envPort, err := strconv.Atoi(os.Getenv(tcpPortEnv)); err == nil && envPort > 0 {
+			port = envPort
+		}
+	}
+	return network, net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// DefaultSocket returns path to the Unix socket of the server from
+// $MYSQL_UNIX_PORT or the first existing default socket.
+func DefaultSocket() string {
+	socket := os.Getenv(unixPortEnv)
+	if socket != "" {
+		return socket
+	}
+	for _, socket = range defaultSockets {
+		if _, err := os.Stat(socket); err == nil {
+			return socket
+		}
+	}
+	return defaultSockets[len(defaultSockets)-1]
+}
@@ -0,0 +1,233 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol.
+package mysql
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+)
+
+// authResponse computes response to the authentication challenge
+// for the given authentication plugin.
+func (c *Conn) authResponse(plugin string, authData []byte) ([]byte, error) {
+	switch plugin {
+	case nativePasswordPlugin:
+		return scrambleNativePassword(authData, c.config.Password), nil
+	case cachingSha2PasswordPlugin:
+		return scrambleCachingSha2Password(authData, c.config.Password), nil
+	case sha256PasswordPlugin:
+		if c.config.Password == "" {
+			return []byte{0}, nil
+		}
+		if c.isSecure() {
+			return append([]byte(c.config.Password), 0), nil
+		}
+		if c.publicKey != nil {
+			return encryptPassword(c.config.Password, authData, c.publicKey)
+		}
+		return []byte{requestPublicKeySha256}, nil
+	}
+	return nil, &errorMysql{"authentication plugin '" + plugin + "' is not supported"}
+}
+
+// readAuthResult reads the result of the authentication phase. The
+// server may ask to switch the authentication method or to continue
+// the authentication exchange with more data, this function handles
+// such requests until OK_Packet or ERR_Packet is received.
+func (c *Conn) readAuthResult(plugin string, authData []byte) error {
+	for {
+		data, err := c.readPacket()
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return &errorMysql{"empty packet"}
+		}
+
+		switch data[0] {
+		case OK_PACKET:
+			_, err = c.parseOkPacket(data)
+			return err
+		case ERR_PACKET:
+			return parseErrPacket(data)
+		case AUTH_SWITCH_PACKET:
+			if len(data) == 1 {
+				return &errorMysql{"authentication plugin 'mysql_old_password' is not supported"}
+			}
+			r := &packetReader{data: data[1:]}
+			plugin = r.nulString()
+			authData = r.rest()
+			// the scramble is terminated by NUL
+			if len(authData) > 0 && authData[len(authData)-1] == 0 {
+				authData = authData[:len(authData)-1]
+			}
+			response, err := c.authResponse(plugin, authData)
+			if err != nil {
+				return err
+			}
+			err = c.writePacket(response)
+			if err != nil {
+				return err
+			}
+		case AUTH_MORE_DATA:
+			err = c.authMoreData(plugin, authData, data[1:])
+			if err != nil {
+				return err
+			}
+		default:
+			return &errorMysql{"unexpected packet during authentication"}
+		}
+	}
+}
+
+// authMoreData handles the AuthMoreData packet sent by
+// caching_sha2_password and sha256_password plugins.
+func (c *Conn) authMoreData(plugin string, authData []byte, data []byte) error {
+	if plugin != cachingSha2PasswordPlugin && plugin != sha256PasswordPlugin {
+		return &errorMysql{"unexpected AuthMoreData packet for '" + plugin + "'"}
+	}
+
+	// the server sent its RSA public key
+	if len(data) > 1 {
+		publicKey, err := ParsePublicKey(data)
+		if err != nil {
+			return err
+		}
+		c.publicKey = publicKey
+		response, err := encryptPassword(c.config.Password, authData, publicKey)
+		if err != nil {
+			return err
+		}
+		return c.writePacket(response)
+	}
+
+	if plugin != cachingSha2PasswordPlugin || len(data) == 0 {
+		return &errorMysql{"malformed AuthMoreData packet"}
+	}
+
+	switch data[0] {
+	case fastAuthSuccess:
+		// OK_Packet follows
+		return nil
+	case performFullAuth:
+		if c.isSecure() {
+			return c.writePacket(append([]byte(c.config.Password), 0))
+		}
+		if c.publicKey != nil {
+			response, err := encryptPassword(c.config.Password, authData, c.publicKey)
+			if err != nil {
+				return err
+			}
+			return c.writePacket(response)
+		}
+		if !c.config.GetServerPublicKey {
+			return &errorMysql{"authentication requires secure connection"}
+		}
+		return c.writePacket([]byte{requestPublicKeyCachingSha2})
+	}
+	return &errorMysql{"malformed AuthMoreData packet"}
+}
+
+// isSecure returns true if the password may be sent to the server
+// in clear text.
+func (c *Conn) isSecure() bool {
+	return c.tls || c.config.Network == "unix"
+}
+
+// scrambleNativePassword computes the mysql_native_password response:
+//
+//	SHA1(password) XOR SHA1(scramble + SHA1(SHA1(password)))
+func scrambleNativePassword(scramble []byte, password string) []byte {
+	if password == "" {
+		return []byte{}
+	}
+	// the scramble is 20 bytes long
+	if len(scramble) > 20 {
+		scramble = scramble[:20]
+	}
+
+	stage1 := sha1.Sum([]byte(password))
+	stage2 := sha1.Sum(stage1[:])
+
+	hash := sha1.New()
+	hash.Write(scramble)
+	hash.Write(stage2[:])
+	result := hash.Sum(nil)
+
+	for i := range result {
+		result[i] ^= stage1[i]
+	}
+	return result
+}
+
+// scrambleCachingSha2Password computes the caching_sha2_password
+// fast authentication response:
+//
+//	SHA256(password) XOR SHA256(SHA256(SHA256(password)) + scramble)
+func scrambleCachingSha2Password(scramble []byte, password string) []byte {
+	if password == "" {
+		return []byte{}
+	}
+
+	stage1 := sha256.Sum256([]byte(password))
+	stage2 := sha256.Sum256(stage1[:])
+
+	hash := sha256.New()
+	hash.Write(stage2[:])
+	hash.Write(scramble)
+	result := hash.Sum(nil)
+
+	for i := range result {
+		result[i] ^= stage1[i]
+	}
+	return result
+}
+
+// encryptPassword encrypts the NUL-terminated password XORed with the
+// scramble by the RSA public key of the server.
+func encryptPassword(password string, scramble []byte, publicKey *rsa.PublicKey) ([]byte, error) {
+	plain := append([]byte(password), 0)
+	if len(scramble) > 0 {
+		for i := range plain {
+			plain[i] ^= scramble[i%len(scramble)]
+		}
+	}
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, publicKey, plain, nil)
+}
+
+// ParsePublicKey parses a PEM encoded RSA public key in the PKIX
+// or PKCS #1 format.
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, &errorMysql{"no PEM data found in the public key"}
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, &errorMysql{"the public key is not RSA public key"}
+	}
+	return publicKey, nil
+}
+
+// readPublicKey reads the RSA public key of the server from the file.
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(data)
+}
@@ -0,0 +1,382 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol.
+package mysql
+
+import (
+	"bufio"
+	"crypto/rsa"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Config describes parameters of a connection to a MySQL server.
+type Config struct {
+	User        string        // MySQL user name
+	Password    string        // password of the user
+	Database    string        // default database, may be empty
+	Network     string        // "tcp" (default) or "unix"
+	Address     string        // host:port or path to the Unix socket
+	BindAddress string        // local address to bind for TCP connections
+	Timeout     time.Duration // timeout of establishing of a connection
+
+	// ServerPublicKeyPath is path to a PEM file with the RSA public key
+	// of the server used by caching_sha2_password and sha256_password.
+	ServerPublicKeyPath string
+	// GetServerPublicKey allows to request the RSA public key from the
+	// server for caching_sha2_password.
+	GetServerPublicKey bool
+
+	SSLMode    SSLMode // security state of the connection
+	SSLCA      string  // path to a PEM file with trusted certificate authorities
+	SSLCert    string  // path to a PEM file with the client certificate
+	SSLKey     string  // path to a PEM file with the client private key
+	TLSVersion string  // comma separated list of permissible TLS versions
+}
+
+// Conn is a connection to a MySQL server.
+type Conn struct {
+	netConn  net.Conn
+	reader   *bufio.Reader
+	config   *Config
+	sequence uint8
+
+	capabilities  uint32
+	status        uint16
+	serverVersion string
+	connectionId  uint32
+	database      string
+	publicKey     *rsa.PublicKey
+	tls           bool
+}
+
+// handshake describes the Initial Handshake Packet (Protocol::HandshakeV10)
+// received from a server.
+type handshake struct {
+	serverVersion string
+	connectionId  uint32
+	authData      []byte
+	capabilities  uint32
+	charset       byte
+	status        uint16
+	authPlugin    string
+}
+
+// Connect opens a new connection to a MySQL server described by the
+// given configuration and authenticates the user.
+func Connect(config *Config) (*Conn, error) {
+	var err error
+	conn := &Conn{}
+	conn.config = config
+
+	if config.ServerPublicKeyPath != "" {
+		conn.publicKey, err = readPublicKey(config.ServerPublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	netConn, err := dial(config)
+	if err != nil {
+		return nil, err
+	}
+	conn.netConn = netConn
+	conn.reader = bufio.NewReader(netConn)
+
+	err = conn.handshake()
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	conn.database = config.Database
+
+	return conn, nil
+}
+
+// dial opens a network connection to the server.
+func dial(config *Config) (net.Conn, error) {
+	network := config.Network
+	if network == "" {
+		network = "tcp"
+	}
+	address := config.Address
+	if address == "" {
+		address = net.JoinHostPort("127.0.0.1", strconv.Itoa(DefaultPort))
+	}
+
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if config.BindAddress != "" && network == "tcp" {
+		localAddr, err := net.ResolveTCPAddr("tcp", net.JoinHostPort(config.BindAddress, "0"))
+		if err != nil {
+			return nil, err
+		}
+		dialer.LocalAddr = localAddr
+	}
+
+	return dialer.Dial(network, address)
+}
+
+// handshake executes the connection phase: reads the initial handshake
+// packet, sends the handshake response and authenticates the user.
+func (c *Conn) handshake() error {
+	data, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 && data[0] == ERR_PACKET {
+		return parseErrPacket(data)
+	}
+
+	hs, err := parseHandshake(data)
+	if err != nil {
+		return err
+	}
+	if hs.capabilities&CLIENT_PROTOCOL_41 == 0 {
+		return &errorMysql{"server does not support protocol 4.1"}
+	}
+	c.serverVersion = hs.serverVersion
+	c.connectionId = hs.connectionId
+	c.status = hs.status
+
+	c.capabilities = CLIENT_LONG_PASSWORD | CLIENT_LONG_FLAG | CLIENT_PROTOCOL_41 |
+		CLIENT_INTERACTIVE | CLIENT_TRANSACTIONS | CLIENT_SECURE_CONNECTION |
+		CLIENT_MULTI_STATEMENTS | CLIENT_MULTI_RESULTS | CLIENT_PLUGIN_AUTH |
+		CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA
+	if c.config.Database != "" {
+		c.capabilities |= CLIENT_CONNECT_WITH_DB
+	}
+	c.capabilities &= hs.capabilities
+
+	if c.config.SSLMode != SSL_MODE_DISABLED {
+		if hs.capabilities&CLIENT_SSL != 0 {
+			c.capabilities |= CLIENT_SSL
+			err = c.startTLS()
+			if err != nil {
+				return err
+			}
+		} else if c.config.SSLMode != SSL_MODE_PREFERRED {
+			return &errorMysql{"SSL connection error: SSL is required but the server doesn't support it"}
+		}
+	}
+
+	plugin := hs.authPlugin
+	if plugin == "" {
+		plugin = nativePasswordPlugin
+	}
+	authResponse, err := c.authResponse(plugin, hs.authData)
+	if err != nil {
+		return err
+	}
+
+	err = c.writeHandshakeResponse(plugin, authResponse)
+	if err != nil {
+		return err
+	}
+
+	return c.readAuthResult(plugin, hs.authData)
+}
+
+// parseHandshake parses the payload of the Initial Handshake Packet.
+func parseHandshake(data []byte) (*handshake, error) {
+	r := &packetReader{data: data}
+	hs := &handshake{}
+
+	version, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	if version != protocolVersion {
+		return nil, &errorMysql{"unsupported protocol version " + strconv.Itoa(int(version))}
+	}
+
+	hs.serverVersion = r.nulString()
+	if hs.connectionId, err = r.uint32(); err != nil {
+		return nil, err
+	}
+	authData, err := r.bytes(8)
+	if err != nil {
+		return nil, err
+	}
+	hs.authData = append(hs.authData, authData...)
+	// filler
+	if _, err = r.byte(); err != nil {
+		return nil, err
+	}
+	capabilities, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	hs.capabilities = uint32(capabilities)
+	if r.eof() {
+		return hs, nil
+	}
+
+	if hs.charset, err = r.byte(); err != nil {
+		return nil, err
+	}
+	if hs.status, err = r.uint16(); err != nil {
+		return nil, err
+	}
+	capabilities, err = r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	hs.capabilities |= uint32(capabilities) << 16
+	authDataLength, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	// reserved
+	if _, err = r.bytes(10); err != nil {
+		return nil, err
+	}
+
+	if hs.capabilities&CLIENT_SECURE_CONNECTION != 0 {
+		length := int(authDataLength) - 8
+		if length < 13 {
+			length = 13
+		}
+		authData, err = r.bytes(length)
+		if err != nil {
+			return nil, err
+		}
+		// the auth-plugin-data is terminated by NUL
+		if authData[len(authData)-1] == 0 {
+			authData = authData[:len(authData)-1]
+		}
+		hs.authData = append(hs.authData, authData...)
+	}
+
+	if hs.capabilities&CLIENT_PLUGIN_AUTH != 0 {
+		hs.authPlugin = r.nulString()
+	}
+
+	return hs, nil
+}
+
+// writeHandshakeResponse sends Protocol::HandshakeResponse41 to the
+// server.
+func (c *Conn) writeHandshakeResponse(plugin string, authResponse []byte) error {
+	payload := c.handshakeResponseHeader()
+	payload = append(payload, c.config.User...)
+	payload = append(payload, 0)
+
+	if c.capabilities&CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA != 0 {
+		payload = appendLenEncInt(payload, uint64(len(authResponse)))
+	} else {
+		payload = append(payload, byte(len(authResponse)))
+	}
+	payload = append(payload, authResponse...)
+
+	if c.capabilities&CLIENT_CONNECT_WITH_DB != 0 {
+		payload = append(payload, c.config.Database...)
+		payload = append(payload, 0)
+	}
+
+	if c.capabilities&CLIENT_PLUGIN_AUTH != 0 {
+		payload = append(payload, plugin...)
+		payload = append(payload, 0)
+	}
+
+	return c.writePacket(payload)
+}
+
+// handshakeResponseHeader returns fixed-length part of the handshake
+// response which is also used as SSLRequest packet: capabilities, max
+// packet size, character set and 23 reserved bytes.
+func (c *Conn) handshakeResponseHeader() []byte {
+	header := make([]byte, 32, 128)
+	binary.LittleEndian.PutUint32(header[0:4], c.capabilities)
+	binary.LittleEndian.PutUint32(header[4:8], maxPacketSize)
+	header[8] = defaultCollation
+	return header
+}
+
+// Query executes the given query with COM_QUERY and returns all result
+// sets produced by it.
+func (c *Conn) Query(query string) ([]*Result, error) {
+	err := c.writeCommand(COM_QUERY, []byte(query))
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*Result{}
+	for {
+		result, err := c.readResult()
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+		if result.Status&SERVER_MORE_RESULTS_EXISTS == 0 {
+			return results, nil
+		}
+	}
+}
+
+// InitDB changes the default database of the connection with
+// COM_INIT_DB.
+func (c *Conn) InitDB(database string) error {
+	err := c.writeCommand(COM_INIT_DB, []byte(database))
+	if err != nil {
+		return err
+	}
+	_, err = c.readOkPacket()
+	if err != nil {
+		return err
+	}
+	c.database = database
+	return nil
+}
+
+// Ping checks whether the server is alive with COM_PING.
+func (c *Conn) Ping() error {
+	err := c.writeCommand(COM_PING, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.readOkPacket()
+	return err
+}
+
+// Close sends COM_QUIT to the server and closes the connection.
+func (c *Conn) Close() error {
+	c.writeCommand(COM_QUIT, nil)
+	return c.netConn.Close()
+}
+
+// ServerVersion returns version of the server the connection
+// is established with.
+func (c *Conn) ServerVersion() string {
+	return c.serverVersion
+}
+
+// ConnectionID returns id of the connection assigned by the
+// server.
+func (c *Conn) ConnectionID() uint32 {
+	return c.connectionId
+}
+
+// Database returns the current default database of the connection.
+func (c *Conn) Database() string {
+	return c.database
+}
+
+// readOkPacket reads a response to a command which returns OK_Packet
+// on success.
+func (c *Conn) readOkPacket() (*Result, error) {
+	data, err := c.readPacket()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, &errorMysql{"empty packet"}
+	}
+	switch data[0] {
+	case OK_PACKET:
+		return c.parseOkPacket(data)
+	case ERR_PACKET:
+		return nil, parseErrPacket(data)
+	}
+	return nil, &errorMysql{"unexpected packet"}
+}
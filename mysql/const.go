@@ -0,0 +1,137 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol. It provides API for connecting to a MySQL server, for the
+// authentication and for the execution of commands:
+//
+//   - COM_QUERY
+//   - COM_INIT_DB
+//   - COM_PING
+//   - COM_QUIT
+//
+// See more info at https://dev.mysql.com/doc/internals/en/client-server-protocol.html
+package mysql
+
+// Capability flags of the client and the server
+const (
+	CLIENT_LONG_PASSWORD                  = 0x00000001 // Use the improved version of Old Password Authentication.
+	CLIENT_FOUND_ROWS                     = 0x00000002 // Send found rows instead of affected rows in EOF_Packet.
+	CLIENT_LONG_FLAG                      = 0x00000004 // Longer flags in Protocol::ColumnDefinition320.
+	CLIENT_CONNECT_WITH_DB                = 0x00000008 // Database (schema) name can be specified on connect in Handshake Response Packet.
+	CLIENT_NO_SCHEMA                      = 0x00000010 // Do not permit database.table.column.
+	CLIENT_COMPRESS                       = 0x00000020 // Compression protocol supported.
+	CLIENT_ODBC                           = 0x00000040 // Special handling of ODBC behavior.
+	CLIENT_LOCAL_FILES                    = 0x00000080 // Can use LOAD DATA LOCAL.
+	CLIENT_IGNORE_SPACE                   = 0x00000100 // Ignore spaces before '('.
+	CLIENT_PROTOCOL_41                    = 0x00000200 // New 4.1 protocol.
+	CLIENT_INTERACTIVE                    = 0x00000400 // This is an interactive client.
+	CLIENT_SSL                            = 0x00000800 // Use SSL encryption for the session.
+	CLIENT_IGNORE_SIGPIPE                 = 0x00001000 // Client only flag. Not used.
+	CLIENT_TRANSACTIONS                   = 0x00002000 // Client knows about transactions.
+	CLIENT_RESERVED                       = 0x00004000 // Deprecated: old flag for 4.1 protocol.
+	CLIENT_SECURE_CONNECTION              = 0x00008000 // Deprecated: old flag for 4.1 authentication.
+	CLIENT_MULTI_STATEMENTS               = 0x00010000 // Enable/disable multi-stmt support.
+	CLIENT_MULTI_RESULTS                  = 0x00020000 // Enable/disable multi-results.
+	CLIENT_PS_MULTI_RESULTS               = 0x00040000 // Multi-results and OUT parameters in PS-protocol.
+	CLIENT_PLUGIN_AUTH                    = 0x00080000 // Client supports plugin authentication.
+	CLIENT_CONNECT_ATTRS                  = 0x00100000 // Client supports connection attributes.
+	CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA = 0x00200000 // Enable authentication response packet to be larger than 255 bytes.
+	CLIENT_CAN_HANDLE_EXPIRED_PASSWORDS   = 0x00400000 // Don't close the connection for a user account with expired password.
+	CLIENT_SESSION_TRACK                  = 0x00800000 // Capable of handling server state change information.
+	CLIENT_DEPRECATE_EOF                  = 0x01000000 // Client no longer needs EOF_Packet and will use OK_Packet instead.
+)
+
+// Server status flags
+const (
+	SERVER_STATUS_IN_TRANS             = 0x0001 // A multi-statement transaction has been started.
+	SERVER_STATUS_AUTOCOMMIT           = 0x0002 // Server in auto_commit mode.
+	SERVER_MORE_RESULTS_EXISTS         = 0x0008 // Multi query - next query exists.
+	SERVER_STATUS_NO_GOOD_INDEX_USED   = 0x0010
+	SERVER_STATUS_NO_INDEX_USED        = 0x0020
+	SERVER_STATUS_CURSOR_EXISTS        = 0x0040
+	SERVER_STATUS_LAST_ROW_SENT        = 0x0080
+	SERVER_STATUS_DB_DROPPED           = 0x0100
+	SERVER_STATUS_NO_BACKSLASH_ESCAPES = 0x0200
+	SERVER_STATUS_METADATA_CHANGED     = 0x0400
+	SERVER_QUERY_WAS_SLOW              = 0x0800
+	SERVER_PS_OUT_PARAMS               = 0x1000
+	SERVER_STATUS_IN_TRANS_READONLY    = 0x2000
+	SERVER_SESSION_STATE_CHANGED       = 0x4000
+)
+
+// Commands of the text protocol
+const (
+	COM_SLEEP   = 0x00
+	COM_QUIT    = 0x01 // Tells the server that the client wants to close the connection.
+	COM_INIT_DB = 0x02 // Change the default schema of the connection.
+	COM_QUERY   = 0x03 // Send the server a text-based query that is executed immediately.
+	COM_PING    = 0x0e // Check if the server is alive.
+)
+
+// Headers of generic response packets
+const (
+	OK_PACKET          = 0x00
+	EOF_PACKET         = 0xfe
+	ERR_PACKET         = 0xff
+	LOCAL_INFILE       = 0xfb
+	NULL_COLUMN        = 0xfb
+	AUTH_SWITCH_PACKET = 0xfe
+	AUTH_MORE_DATA     = 0x01
+)
+
+// Column types
+const (
+	MYSQL_TYPE_DECIMAL     = 0x00
+	MYSQL_TYPE_TINY        = 0x01
+	MYSQL_TYPE_SHORT       = 0x02
+	MYSQL_TYPE_LONG        = 0x03
+	MYSQL_TYPE_FLOAT       = 0x04
+	MYSQL_TYPE_DOUBLE      = 0x05
+	MYSQL_TYPE_NULL        = 0x06
+	MYSQL_TYPE_TIMESTAMP   = 0x07
+	MYSQL_TYPE_LONGLONG    = 0x08
+	MYSQL_TYPE_INT24       = 0x09
+	MYSQL_TYPE_DATE        = 0x0a
+	MYSQL_TYPE_TIME        = 0x0b
+	MYSQL_TYPE_DATETIME    = 0x0c
+	MYSQL_TYPE_YEAR        = 0x0d
+	MYSQL_TYPE_NEWDATE     = 0x0e
+	MYSQL_TYPE_VARCHAR     = 0x0f
+	MYSQL_TYPE_BIT         = 0x10
+	MYSQL_TYPE_JSON        = 0xf5
+	MYSQL_TYPE_NEWDECIMAL  = 0xf6
+	MYSQL_TYPE_ENUM        = 0xf7
+	MYSQL_TYPE_SET         = 0xf8
+	MYSQL_TYPE_TINY_BLOB   = 0xf9
+	MYSQL_TYPE_MEDIUM_BLOB = 0xfa
+	MYSQL_TYPE_LONG_BLOB   = 0xfb
+	MYSQL_TYPE_BLOB        = 0xfc
+	MYSQL_TYPE_VAR_STRING  = 0xfd
+	MYSQL_TYPE_STRING      = 0xfe
+	MYSQL_TYPE_GEOMETRY    = 0xff
+)
+
+// protocol version supported by the client
+const protocolVersion = 10
+
+// maximum length of payload of a single packet
+const maxPacketSize = 1<<24 - 1
+
+// default character set of a connection (utf8mb4_general_ci)
+const defaultCollation = 45
+
+// names of authentication plugins
+const (
+	nativePasswordPlugin      = "mysql_native_password"
+	cachingSha2PasswordPlugin = "caching_sha2_password"
+	sha256PasswordPlugin      = "sha256_password"
+)
+
+// caching_sha2_password and sha256_password protocol messages
+const (
+	requestPublicKeyCachingSha2 = 0x02 // request of the server RSA public key by caching_sha2_password
+	requestPublicKeySha256      = 0x01 // request of the server RSA public key by sha256_password
+	fastAuthSuccess             = 0x03 // the password was found in the server cache
+	performFullAuth             = 0x04 // the server requires full authentication
+)
+
+// default port of a MySQL server
+const DefaultPort = 3306
@@ -0,0 +1,47 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol.
+package mysql
+
+import "fmt"
+
+// errorMysql is an implementation of error interface which
+// provides error message for the client side errors of the
+// mysql package.
+type errorMysql struct {
+	errMsg string
+}
+
+func (err errorMysql) Error() string {
+	return err.errMsg
+}
+
+// ServerError describes an ERR_Packet received from a MySQL
+// server.
+type ServerError struct {
+	Code    uint16 // error code
+	State   string // SQL state
+	Message string // human readable error message
+}
+
+func (err *ServerError) Error() string {
+	if err.State == "" {
+		return fmt.Sprintf("ERROR %d: %s", err.Code, err.Message)
+	}
+	return fmt.Sprintf("ERROR %d (%s): %s", err.Code, err.State, err.Message)
+}
+
+// parseErrPacket parses payload of an ERR_Packet and returns
+// *ServerError.
+func parseErrPacket(data []byte) error {
+	if len(data) < 3 || data[0] != ERR_PACKET {
+		return &errorMysql{"malformed ERR packet"}
+	}
+	err := &ServerError{Code: uint16(data[1]) | uint16(data[2])<<8}
+	data = data[3:]
+	if len(data) >= 6 && data[0] == '#' {
+		err.State = string(data[1:6])
+		data = data[6:]
+	}
+	err.Message = string(data)
+	return err
+}
@@ -0,0 +1,874 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol.
+package mysql
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer is an in-process MySQL server which implements just
+// enough of the protocol to test the client.
+type fakeServer struct {
+	listener net.Listener
+	user     string
+	password string
+	database string
+	scramble []byte
+
+	plugin     string          // authentication plugin announced in the handshake
+	switchTo   string          // authentication plugin requested by AuthSwitchRequest
+	cached     bool            // caching_sha2_password cache contains the user
+	privateKey *rsa.PrivateKey // RSA key pair of the server
+	tlsConfig  *tls.Config     // TLS configuration, TLS is disabled if nil
+}
+
+// fakeConn is a connection accepted by the fakeServer.
+type fakeConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	sequence uint8
+	database string
+}
+
+// bufferedConn reads from the buffered reader of a connection.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func newFakeServer(t *testing.T, user string, password string) *fakeServer {
+	return newFakeServerOn(t, "tcp", "127.0.0.1:0", user, password)
+}
+
+func newFakeServerOn(t *testing.T, network string, address string, user string, password string) *fakeServer {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal("Listen failed")
+	}
+	server := &fakeServer{listener: listener, user: user, password: password}
+	server.plugin = nativePasswordPlugin
+	server.scramble = []byte("abcdefghijklmnopqrst")
+	return server
+}
+
+// start starts accepting of connections, the server must not be
+// modified after it.
+func (s *fakeServer) start() {
+	go s.serve()
+}
+
+func (s *fakeServer) config() *Config {
+	address := s.listener.Addr()
+	return &Config{User: s.user, Password: s.password, Network: address.Network(), Address: address.String()}
+}
+
+func (s *fakeServer) close() {
+	s.listener.Close()
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(&fakeConn{conn: conn, reader: bufio.NewReader(conn)})
+	}
+}
+
+func (s *fakeServer) handle(c *fakeConn) {
+	defer c.conn.Close()
+
+	// initial handshake
+	hs := []byte{protocolVersion}
+	hs = append(hs, "8.0.0-fake"...)
+	hs = append(hs, 0, 1, 0, 0, 0)
+	hs = append(hs, s.scramble[:8]...)
+	hs = append(hs, 0)
+	capabilities := uint32(CLIENT_LONG_PASSWORD | CLIENT_PROTOCOL_41 | CLIENT_SECURE_CONNECTION |
+		CLIENT_PLUGIN_AUTH | CLIENT_CONNECT_WITH_DB | CLIENT_MULTI_RESULTS |
+		CLIENT_PLUGIN_AUTH_LENENC_CLIENT_DATA)
+	if s.tlsConfig != nil {
+		capabilities |= CLIENT_SSL
+	}
+	hs = append(hs, byte(capabilities), byte(capabilities>>8), defaultCollation, 2, 0,
+		byte(capabilities>>16), byte(capabilities>>24), 21)
+	hs = append(hs, make([]byte, 10)...)
+	hs = append(hs, s.scramble[8:]...)
+	hs = append(hs, 0)
+	hs = append(hs, s.plugin...)
+	hs = append(hs, 0)
+	c.writePacket(hs)
+
+	// handshake response
+	data, err := c.readPacket()
+	if err != nil {
+		return
+	}
+	if len(data) == 32 && s.tlsConfig != nil {
+		// SSLRequest packet, the client hello may be already buffered
+		tlsConn := tls.Server(&bufferedConn{c.conn, c.reader}, s.tlsConfig)
+		if tlsConn.Handshake() != nil {
+			return
+		}
+		c.conn = tlsConn
+		c.reader = bufio.NewReader(tlsConn)
+		data, err = c.readPacket()
+		if err != nil {
+			return
+		}
+	}
+	r := &packetReader{data: data}
+	clientCapabilities, _ := r.uint32()
+	r.bytes(28)
+	user := r.nulString()
+	length, _, _ := r.lenEncInt()
+	authResponse, _ := r.bytes(int(length))
+	if clientCapabilities&CLIENT_CONNECT_WITH_DB != 0 {
+		c.database = r.nulString()
+	}
+
+	plugin := s.plugin
+	scramble := s.scramble
+	if s.switchTo != "" {
+		plugin = s.switchTo
+		scramble = []byte("ABCDEFGHIJKLMNOPQRST")
+		data := []byte{AUTH_SWITCH_PACKET}
+		data = append(data, plugin...)
+		data = append(data, 0)
+		data = append(data, scramble...)
+		data = append(data, 0)
+		c.writePacket(data)
+		authResponse, err = c.readPacket()
+		if err != nil {
+			return
+		}
+	}
+
+	if user != s.user || !s.authenticate(c, plugin, scramble, authResponse) {
+		c.writeErr(1045, "28000", "Access denied for user '"+user+"'")
+		return
+	}
+	c.writeOK(0, "")
+
+	// command phase
+	for {
+		c.sequence = 0
+		data, err := c.readPacket()
+		if err != nil || len(data) == 0 {
+			return
+		}
+		switch data[0] {
+		case COM_QUIT:
+			return
+		case COM_PING:
+			c.writeOK(0, "")
+		case COM_INIT_DB:
+			if string(data[1:]) == "missing" {
+				c.writeErr(1049, "42000", "Unknown database 'missing'")
+				break
+			}
+			c.database = string(data[1:])
+			c.writeOK(0, "")
+		case COM_QUERY:
+			s.query(c, string(data[1:]))
+		}
+	}
+}
+
+// authenticate verifies the authentication response of the client
+// for the given plugin.
+func (s *fakeServer) authenticate(c *fakeConn, plugin string, scramble []byte, response []byte) bool {
+	switch plugin {
+	case nativePasswordPlugin:
+		return checkNativePassword(scramble, response, s.password)
+	case cachingSha2PasswordPlugin:
+		if len(response) == 0 {
+			return s.password == ""
+		}
+		if s.cached {
+			if !checkCachingSha2Password(scramble, response, s.password) {
+				return false
+			}
+			c.writePacket([]byte{AUTH_MORE_DATA, fastAuthSuccess})
+			return true
+		}
+		c.writePacket([]byte{AUTH_MORE_DATA, performFullAuth})
+		response, err := c.readPacket()
+		if err != nil {
+			return false
+		}
+		if len(response) == 1 && response[0] == requestPublicKeyCachingSha2 {
+			response = s.sendPublicKey(c)
+		}
+		return s.checkFullAuth(scramble, response)
+	case sha256PasswordPlugin:
+		if len(response) == 1 && response[0] == requestPublicKeySha256 {
+			response = s.sendPublicKey(c)
+		}
+		return s.checkFullAuth(scramble, response)
+	}
+	return false
+}
+
+// sendPublicKey sends the RSA public key of the server to the client
+// and returns the response.
+func (s *fakeServer) sendPublicKey(c *fakeConn) []byte {
+	der, _ := x509.MarshalPKIXPublicKey(&s.privateKey.PublicKey)
+	data := []byte{AUTH_MORE_DATA}
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})...)
+	c.writePacket(data)
+	response, _ := c.readPacket()
+	return response
+}
+
+// checkFullAuth verifies a clear text or RSA encrypted password.
+func (s *fakeServer) checkFullAuth(scramble []byte, response []byte) bool {
+	if bytes.Equal(response, append([]byte(s.password), 0)) {
+		return true
+	}
+	if s.privateKey == nil {
+		return false
+	}
+	plain, err := rsa.DecryptOAEP(sha1.New(), nil, s.privateKey, response, nil)
+	if err != nil {
+		return false
+	}
+	for i := range plain {
+		plain[i] ^= scramble[i%len(scramble)]
+	}
+	return bytes.Equal(plain, append([]byte(s.password), 0))
+}
+
+func (s *fakeServer) query(c *fakeConn, query string) {
+	switch query {
+	case "SELECT DATABASE()":
+		c.writeResultSet([]string{"DATABASE()"}, [][]*string{{&c.database}})
+	case "SHOW DATABASES":
+		names := []string{"information_schema", "mysql", "test"}
+		rows := [][]*string{}
+		for i := range names {
+			rows = append(rows, []*string{&names[i]})
+		}
+		c.writeResultSet([]string{"Database"}, rows)
+	case "SELECT 1, NULL":
+		one := "1"
+		c.writeResultSet([]string{"1", "NULL"}, [][]*string{{&one, nil}})
+	case "DELETE FROM t":
+		c.writeOK(3, "")
+	default:
+		c.writeErr(1064, "42000", "You have an error in your SQL syntax")
+	}
+}
+
+func (c *fakeConn) readPacket() ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return nil, err
+	}
+	c.sequence = header[3] + 1
+	data := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	_, err := io.ReadFull(c.reader, data)
+	return data, err
+}
+
+func (c *fakeConn) writePacket(data []byte) {
+	header := []byte{byte(len(data)), byte(len(data) >> 8), byte(len(data) >> 16), c.sequence}
+	c.conn.Write(append(header, data...))
+	c.sequence++
+}
+
+func (c *fakeConn) writeOK(affectedRows uint64, info string) {
+	data := []byte{OK_PACKET}
+	data = appendLenEncInt(data, affectedRows)
+	data = appendLenEncInt(data, 0)
+	data = append(data, SERVER_STATUS_AUTOCOMMIT, 0, 0, 0)
+	data = append(data, info...)
+	c.writePacket(data)
+}
+
+func (c *fakeConn) writeErr(code uint16, state string, message string) {
+	data := []byte{ERR_PACKET, byte(code), byte(code >> 8), '#'}
+	data = append(data, state...)
+	data = append(data, message...)
+	c.writePacket(data)
+}
+
+func (c *fakeConn) writeEOF() {
+	c.writePacket([]byte{EOF_PACKET, 0, 0, SERVER_STATUS_AUTOCOMMIT, 0})
+}
+
+func (c *fakeConn) writeResultSet(columns []string, rows [][]*string) {
+	c.writePacket(appendLenEncInt(nil, uint64(len(columns))))
+	for _, name := range columns {
+		data := appendLenEncString(nil, "def")
+		data = appendLenEncString(data, "")
+		data = appendLenEncString(data, "")
+		data = appendLenEncString(data, "")
+		data = appendLenEncString(data, name)
+		data = appendLenEncString(data, "")
+		data = append(data, 0x0c, defaultCollation, 0, 0, 1, 0, 0, MYSQL_TYPE_VAR_STRING, 0, 0, 0, 0, 0)
+		c.writePacket(data)
+	}
+	c.writeEOF()
+	for _, row := range rows {
+		data := []byte{}
+		for _, value := range row {
+			if value == nil {
+				data = append(data, NULL_COLUMN)
+				continue
+			}
+			data = appendLenEncString(data, *value)
+		}
+		c.writePacket(data)
+	}
+	c.writeEOF()
+}
+
+// checkNativePassword verifies mysql_native_password response the same
+// way as a MySQL server does it.
+func checkNativePassword(scramble []byte, response []byte, password string) bool {
+	if password == "" {
+		return len(response) == 0
+	}
+	if len(response) != sha1.Size {
+		return false
+	}
+	stage1 := sha1.Sum([]byte(password))
+	stored := sha1.Sum(stage1[:])
+
+	hash := sha1.New()
+	hash.Write(scramble)
+	hash.Write(stored[:])
+	candidate := hash.Sum(nil)
+	for i := range candidate {
+		candidate[i] ^= response[i]
+	}
+	check := sha1.Sum(candidate)
+	return bytes.Equal(check[:], stored[:])
+}
+
+// checkCachingSha2Password verifies caching_sha2_password fast
+// authentication response.
+func checkCachingSha2Password(scramble []byte, response []byte, password string) bool {
+	stage1 := sha256.Sum256([]byte(password))
+	stored := sha256.Sum256(stage1[:])
+
+	hash := sha256.New()
+	hash.Write(stored[:])
+	hash.Write(scramble)
+	candidate := hash.Sum(nil)
+	if len(response) != len(candidate) {
+		return false
+	}
+	for i := range candidate {
+		candidate[i] ^= response[i]
+	}
+	check := sha256.Sum256(candidate)
+	return bytes.Equal(check[:], stored[:])
+}
+
+func TestLenEncInt(t *testing.T) {
+	for _, n := range []uint64{0, 250, 251, 1 << 16, 1 << 24, 1 << 40} {
+		r := &packetReader{data: appendLenEncInt(nil, n)}
+		value, null, err := r.lenEncInt()
+		if err != nil || null || value != n || !r.eof() {
+			t.Error("lenEncInt failed", n)
+		}
+	}
+
+	r := &packetReader{data: []byte{NULL_COLUMN}}
+	_, null, err := r.lenEncString()
+	if err != nil || !null {
+		t.Error("lenEncString failed (NULL)")
+	}
+}
+
+func TestConnect(t *testing.T) {
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+
+	server.start()
+	config := server.config()
+	config.Database = "test"
+	conn, err := Connect(config)
+	if err != nil {
+		t.Fatal("Connect failed", err)
+	}
+	defer conn.Close()
+
+	if conn.ServerVersion() != "8.0.0-fake" {
+		t.Error("ServerVersion failed")
+	}
+
+	if conn.ConnectionID() != 1 {
+		t.Error("ConnectionID failed")
+	}
+
+	if conn.Database() != "test" {
+		t.Error("Database failed")
+	}
+
+	err = conn.Ping()
+	if err != nil {
+		t.Error("Ping failed", err)
+	}
+}
+
+func TestConnectAccessDenied(t *testing.T) {
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+
+	server.start()
+	config := server.config()
+	config.Password = "wrong"
+	_, err := Connect(config)
+	serverErr, ok := err.(*ServerError)
+	if !ok {
+		t.Fatal("Connect with wrong password must fail with ServerError")
+	}
+	if serverErr.Code != 1045 || serverErr.State != "28000" {
+		t.Error("Wrong ServerError", serverErr)
+	}
+}
+
+func TestEmptyPassword(t *testing.T) {
+	server := newFakeServer(t, "guest", "")
+	defer server.close()
+
+	server.start()
+	conn, err := Connect(server.config())
+	if err != nil {
+		t.Fatal("Connect without password failed", err)
+	}
+	conn.Close()
+}
+
+func TestQuery(t *testing.T) {
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+
+	server.start()
+	conn, err := Connect(server.config())
+	if err != nil {
+		t.Fatal("Connect failed", err)
+	}
+	defer conn.Close()
+
+	results, err := conn.Query("SELECT 1, NULL")
+	if err != nil || len(results) != 1 {
+		t.Fatal("Query failed", err)
+	}
+	result := results[0]
+	if len(result.Columns) != 2 || result.Columns[1].Name != "NULL" {
+		t.Error("Query failed (columns)")
+	}
+	if len(result.Rows) != 1 || result.Rows[0][0].Data != "1" || !result.Rows[0][1].Null {
+		t.Error("Query failed (rows)")
+	}
+
+	results, err = conn.Query("DELETE FROM t")
+	if err != nil || results[0].AffectedRows != 3 || len(results[0].Columns) != 0 {
+		t.Error("Query failed (OK packet)")
+	}
+
+	_, err = conn.Query("SELEC")
+	if err == nil || !strings.HasPrefix(err.Error(), "ERROR 1064 (42000)") {
+		t.Error("Query failed (ERR packet)", err)
+	}
+
+	// the connection must be usable after an error
+	err = conn.Ping()
+	if err != nil {
+		t.Error("Ping after error failed", err)
+	}
+}
+
+func TestInitDB(t *testing.T) {
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+
+	server.start()
+	conn, err := Connect(server.config())
+	if err != nil {
+		t.Fatal("Connect failed", err)
+	}
+	defer conn.Close()
+
+	err = conn.InitDB("mysql")
+	if err != nil || conn.Database() != "mysql" {
+		t.Error("InitDB failed", err)
+	}
+
+	results, err := conn.Query("SELECT DATABASE()")
+	if err != nil || results[0].Rows[0][0].Data != "mysql" {
+		t.Error("InitDB failed (SELECT DATABASE())")
+	}
+
+	err = conn.InitDB("missing")
+	if err == nil || conn.Database() != "mysql" {
+		t.Error("InitDB with unknown database must fail")
+	}
+}
+
+func TestPacketSplit(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	conn := &Conn{netConn: client, reader: bufio.NewReader(client)}
+	payload := bytes.Repeat([]byte{'x'}, maxPacketSize+10)
+	go conn.writePacket(payload)
+
+	reader := &Conn{netConn: server, reader: bufio.NewReader(server)}
+	data, err := reader.readPacket()
+	if err != nil || len(data) != len(payload) {
+		t.Error("readPacket failed (split packet)")
+	}
+	if binary.LittleEndian.Uint16(data[:2]) != uint16('x')|uint16('x')<<8 {
+		t.Error("readPacket failed (payload)")
+	}
+}
+
+func TestCachingSha2FastAuth(t *testing.T) {
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+	server.plugin = cachingSha2PasswordPlugin
+	server.cached = true
+
+	server.start()
+	conn, err := Connect(server.config())
+	if err != nil {
+		t.Fatal("Connect with caching_sha2_password failed", err)
+	}
+	conn.Close()
+
+	config := server.config()
+	config.Password = "wrong"
+	_, err = Connect(config)
+	if err == nil {
+		t.Error("Connect with wrong password must fail")
+	}
+}
+
+func TestCachingSha2FullAuth(t *testing.T) {
+	var err error
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+	server.plugin = cachingSha2PasswordPlugin
+	server.privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("GenerateKey failed")
+	}
+
+	server.start()
+
+	// the public key can't be requested without GetServerPublicKey
+	_, err = Connect(server.config())
+	if err == nil {
+		t.Error("Connect without public key must fail on insecure connection")
+	}
+
+	config := server.config()
+	config.GetServerPublicKey = true
+	conn, err := Connect(config)
+	if err != nil {
+		t.Fatal("Connect with requested public key failed", err)
+	}
+	conn.Close()
+
+	// the public key is read from a file
+	der, _ := x509.MarshalPKIXPublicKey(&server.privateKey.PublicKey)
+	path := filepath.Join(t.TempDir(), "public_key.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal("WriteFile failed")
+	}
+	config = server.config()
+	config.ServerPublicKeyPath = path
+	conn, err = Connect(config)
+	if err != nil {
+		t.Fatal("Connect with ServerPublicKeyPath failed", err)
+	}
+	conn.Close()
+}
+
+func TestSha256Password(t *testing.T) {
+	var err error
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+	server.plugin = sha256PasswordPlugin
+	server.privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("GenerateKey failed")
+	}
+
+	server.start()
+	conn, err := Connect(server.config())
+	if err != nil {
+		t.Fatal("Connect with sha256_password failed", err)
+	}
+	conn.Close()
+}
+
+func TestAuthSwitch(t *testing.T) {
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+	server.plugin = cachingSha2PasswordPlugin
+	server.switchTo = nativePasswordPlugin
+
+	server.start()
+	conn, err := Connect(server.config())
+	if err != nil {
+		t.Fatal("Connect with AuthSwitchRequest failed", err)
+	}
+	conn.Close()
+}
+
+// newCertificate generates a certificate signed by the parent or
+// self-signed certificate if the parent is nil.
+func newCertificate(t *testing.T, template *x509.Certificate, parent *tls.Certificate) (*tls.Certificate, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("GenerateKey failed")
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	issuer, signer := template, interface{}(key)
+	if parent != nil {
+		issuer = parent.Leaf
+		signer = parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal("CreateCertificate failed", err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	certificate := &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+	return certificate, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// newTLSServer returns fake server with a certificate signed by a locally
+// generated CA and path to the CA certificate.
+func newTLSServer(t *testing.T, host string) (*fakeServer, string) {
+	ca, caPem := newCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	serverCert, _ := newCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		DNSNames:     []string{host},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caPath, caPem, 0600)
+	if err != nil {
+		t.Fatal("WriteFile failed")
+	}
+
+	server := newFakeServer(t, "root", "secret")
+	server.tlsConfig = &tls.Config{Certificates: []tls.Certificate{*serverCert}}
+	return server, caPath
+}
+
+func TestParseSSLMode(t *testing.T) {
+	mode, err := ParseSSLMode("verify_identity")
+	if err != nil || mode != SSL_MODE_VERIFY_IDENTITY {
+		t.Error("ParseSSLMode failed")
+	}
+
+	_, err = ParseSSLMode("ALWAYS")
+	if err == nil {
+		t.Error("ParseSSLMode must fail on unknown mode")
+	}
+}
+
+func TestSSLPreferred(t *testing.T) {
+	server, _ := newTLSServer(t, "localhost")
+	defer server.close()
+	server.plugin = cachingSha2PasswordPlugin
+	server.start()
+
+	// full authentication sends the password in clear text over TLS
+	conn, err := Connect(server.config())
+	if err != nil {
+		t.Fatal("Connect over TLS failed", err)
+	}
+	_, ok := conn.TLSConnectionState()
+	if !ok {
+		t.Error("SSL_MODE_PREFERRED must use TLS")
+	}
+	err = conn.Ping()
+	if err != nil {
+		t.Error("Ping over TLS failed", err)
+	}
+	conn.Close()
+
+	// the full authentication over unencrypted connection requires
+	// the public key of the server
+	config := server.config()
+	config.SSLMode = SSL_MODE_DISABLED
+	_, err = Connect(config)
+	if err == nil {
+		t.Error("Connect must fail without public key")
+	}
+}
+
+func TestSSLRequired(t *testing.T) {
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+	server.start()
+
+	config := server.config()
+	config.SSLMode = SSL_MODE_REQUIRED
+	_, err := Connect(config)
+	if err == nil {
+		t.Error("SSL_MODE_REQUIRED must fail if the server does not support TLS")
+	}
+
+	config.SSLMode = SSL_MODE_PREFERRED
+	conn, err := Connect(config)
+	if err != nil {
+		t.Fatal("SSL_MODE_PREFERRED must fall back to unencrypted connection", err)
+	}
+	_, ok := conn.TLSConnectionState()
+	if ok {
+		t.Error("Connection must not be encrypted")
+	}
+	conn.Close()
+}
+
+func TestSSLVerify(t *testing.T) {
+	server, caPath := newTLSServer(t, "localhost")
+	defer server.close()
+	server.start()
+
+	config := server.config()
+	config.SSLMode = SSL_MODE_VERIFY_CA
+	config.SSLCA = caPath
+	config.TLSVersion = "TLSv1.2,TLSv1.3"
+	conn, err := Connect(config)
+	if err != nil {
+		t.Fatal("SSL_MODE_VERIFY_CA failed", err)
+	}
+	conn.Close()
+
+	// the certificate is issued for "localhost", not for 127.0.0.1
+	config.SSLMode = SSL_MODE_VERIFY_IDENTITY
+	_, err = Connect(config)
+	if err == nil {
+		t.Error("SSL_MODE_VERIFY_IDENTITY must check the host name")
+	}
+
+	_, otherCA := newTLSServer(t, "localhost")
+	config.SSLMode = SSL_MODE_VERIFY_CA
+	config.SSLCA = otherCA
+	_, err = Connect(config)
+	if err == nil {
+		t.Error("SSL_MODE_VERIFY_CA must fail with unknown CA")
+	}
+}
+
+func TestResolveAddress(t *testing.T) {
+	os.Unsetenv("MYSQL_HOST")
+	os.Unsetenv("MYSQL_TCP_PORT")
+	os.Setenv("MYSQL_UNIX_PORT", "/run/test.sock")
+
+	network, address, err := ResolveAddress("", 0, "", "")
+	if err != nil || network != "unix" || address != "/run/test.sock" {
+		t.Error("ResolveAddress failed (default)", network, address)
+	}
+
+	network, address, err = ResolveAddress("localhost", 3307, "/tmp/other.sock", "")
+	if err != nil || network != "unix" || address != "/tmp/other.sock" {
+		t.Error("ResolveAddress failed (localhost)", network, address)
+	}
+
+	network, address, err = ResolveAddress("127.0.0.1", 0, "", "")
+	if err != nil || network != "tcp" || address != "127.0.0.1:3306" {
+		t.Error("ResolveAddress failed (127.0.0.1)", network, address)
+	}
+
+	network, address, err = ResolveAddress("localhost", 3307, "", "tcp")
+	if err != nil || network != "tcp" || address != "localhost:3307" {
+		t.Error("ResolveAddress failed (--protocol=TCP)", network, address)
+	}
+
+	network, address, err = ResolveAddress("::1", 0, "", "")
+	if err != nil || network != "tcp" || address != "[::1]:3306" {
+		t.Error("ResolveAddress failed (IPv6)", network, address)
+	}
+
+	_, _, err = ResolveAddress("db", 0, "", "PIPE")
+	if err == nil {
+		t.Error("ResolveAddress must fail with --protocol=PIPE")
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mysqld.sock")
+	server := newFakeServerOn(t, "unix", path, "root", "secret")
+	defer server.close()
+	server.plugin = cachingSha2PasswordPlugin
+	server.start()
+
+	// the password is sent in clear text via the Unix socket and
+	// the bind address is ignored
+	config := server.config()
+	config.BindAddress = "192.0.2.1"
+	conn, err := Connect(config)
+	if err != nil {
+		t.Fatal("Connect via Unix socket failed", err)
+	}
+	conn.Close()
+}
+
+func TestBindAddress(t *testing.T) {
+	server := newFakeServer(t, "root", "secret")
+	defer server.close()
+	server.start()
+
+	config := server.config()
+	config.BindAddress = "127.0.0.1"
+	conn, err := Connect(config)
+	if err != nil {
+		t.Fatal("Connect with BindAddress failed", err)
+	}
+	local := conn.netConn.LocalAddr().(*net.TCPAddr)
+	if !local.IP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Error("BindAddress is not used", local)
+	}
+	conn.Close()
+
+	config.BindAddress = "192.0.2.1"
+	_, err = Connect(config)
+	if err == nil {
+		t.Error("Connect with foreign BindAddress must fail")
+	}
+}
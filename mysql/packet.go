@@ -0,0 +1,210 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol.
+package mysql
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// readPacket reads a whole logical packet from the server. Payloads
+// which are longer than 16MB are sent as a sequence of packets, all of
+// them are concatenated here.
+func (c *Conn) readPacket() ([]byte, error) {
+	var payload []byte
+	header := make([]byte, 4)
+
+	for {
+		if _, err := io.ReadFull(c.reader, header); err != nil {
+			return nil, err
+		}
+		length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+		if header[3] != c.sequence {
+			return nil, &errorMysql{"packets out of order"}
+		}
+		c.sequence++
+
+		data := make([]byte, length)
+		if _, err := io.ReadFull(c.reader, data); err != nil {
+			return nil, err
+		}
+		payload = append(payload, data...)
+
+		if length < maxPacketSize {
+			return payload, nil
+		}
+	}
+}
+
+// writePacket writes the given payload to the server splitting it
+// to the 16MB chunks if it is necessary.
+func (c *Conn) writePacket(payload []byte) error {
+	for {
+		length := len(payload)
+		if length > maxPacketSize {
+			length = maxPacketSize
+		}
+		packet := make([]byte, 4, 4+length)
+		packet[0] = byte(length)
+		packet[1] = byte(length >> 8)
+		packet[2] = byte(length >> 16)
+		packet[3] = c.sequence
+		packet = append(packet, payload[:length]...)
+		if _, err := c.netConn.Write(packet); err != nil {
+			return err
+		}
+		c.sequence++
+		payload = payload[length:]
+
+		// a payload with length of exactly 16MB must be
+		// followed by an empty packet
+		if length < maxPacketSize {
+			return nil
+		}
+	}
+}
+
+// writeCommand starts a new command phase and sends the command
+// with the given arguments to the server.
+func (c *Conn) writeCommand(command byte, arg []byte) error {
+	c.sequence = 0
+	payload := make([]byte, 0, 1+len(arg))
+	payload = append(payload, command)
+	payload = append(payload, arg...)
+	return c.writePacket(payload)
+}
+
+// packetReader provides helpers for decoding of the protocol basic
+// data types from a packet payload.
+type packetReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *packetReader) eof() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *packetReader) byte() (byte, error) {
+	if r.pos+1 > len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *packetReader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *packetReader) uint16() (uint16, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *packetReader) uint32() (uint32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// lenEncInt reads a length-encoded integer. The second returned value
+// is true if the integer is a NULL marker.
+func (r *packetReader) lenEncInt() (uint64, bool, error) {
+	first, err := r.byte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch first {
+	case 0xfb:
+		return 0, true, nil
+	case 0xfc:
+		b, err := r.bytes(2)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b[0]) | uint64(b[1])<<8, false, nil
+	case 0xfd:
+		b, err := r.bytes(3)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16, false, nil
+	case 0xfe:
+		b, err := r.bytes(8)
+		if err != nil {
+			return 0, false, err
+		}
+		return binary.LittleEndian.Uint64(b), false, nil
+	}
+	return uint64(first), false, nil
+}
+
+// lenEncString reads a length-encoded string. The second returned
+// value is true if the string is NULL.
+func (r *packetReader) lenEncString() (string, bool, error) {
+	length, null, err := r.lenEncInt()
+	if err != nil || null {
+		return "", null, err
+	}
+	b, err := r.bytes(int(length))
+	if err != nil {
+		return "", false, err
+	}
+	return string(b), false, nil
+}
+
+// nulString reads a string terminated by NUL. If there is no NUL
+// the rest of packet is returned.
+func (r *packetReader) nulString() string {
+	start := r.pos
+	for r.pos < len(r.data) {
+		if r.data[r.pos] == 0 {
+			s := string(r.data[start:r.pos])
+			r.pos++
+			return s
+		}
+		r.pos++
+	}
+	return string(r.data[start:])
+}
+
+// rest returns the rest of the packet payload.
+func (r *packetReader) rest() []byte {
+	b := r.data[r.pos:]
+	r.pos = len(r.data)
+	return b
+}
+
+// appendLenEncInt appends the given integer to the buffer as a
+// length-encoded integer.
+func appendLenEncInt(buf []byte, n uint64) []byte {
+	switch {
+	case n < 251:
+		return append(buf, byte(n))
+	case n < 1<<16:
+		return append(buf, 0xfc, byte(n), byte(n>>8))
+	case n < 1<<24:
+		return append(buf, 0xfd, byte(n), byte(n>>8), byte(n>>16))
+	}
+	return append(buf, 0xfe, byte(n), byte(n>>8), byte(n>>16), byte(n>>24),
+		byte(n>>32), byte(n>>40), byte(n>>48), byte(n>>56))
+}
+
+// appendLenEncString appends the given string to the buffer as a
+// length-encoded string.
+func appendLenEncString(buf []byte, s string) []byte {
+	buf = appendLenEncInt(buf, uint64(len(s)))
+	return append(buf, s...)
+}
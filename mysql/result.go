@@ -0,0 +1,198 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol.
+package mysql
+
+// Column describes a column of a result set (Protocol::ColumnDefinition41).
+type Column struct {
+	Schema   string // database name
+	Table    string // virtual table name
+	OrgTable string // physical table name
+	Name     string // virtual column name
+	OrgName  string // physical column name
+	Charset  uint16 // character set of the column
+	Length   uint32 // maximum length of the field
+	Type     byte   // type of the column (see MYSQL_TYPE_*)
+	Flags    uint16 // column flags
+	Decimals byte   // max shown decimal digits
+}
+
+// Value is a value of a field in a row of a result set.
+type Value struct {
+	Data string // text representation of the value
+	Null bool   // true if the value is NULL
+}
+
+// Result is a result of a command. Queries which do not return a result
+// set have empty Columns and Rows.
+type Result struct {
+	Columns      []Column  // columns of a result set
+	Rows         [][]Value // rows of a result set
+	AffectedRows uint64    // number of affected rows
+	LastInsertId uint64    // last insert id
+	Status       uint16    // server status flags (see SERVER_*)
+	Warnings     uint16    // number of warnings
+	Info         string    // human readable status information
+}
+
+// readResult reads a response to COM_QUERY.
+func (c *Conn) readResult() (*Result, error) {
+	data, err := c.readPacket()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, &errorMysql{"empty packet"}
+	}
+
+	switch data[0] {
+	case OK_PACKET:
+		return c.parseOkPacket(data)
+	case ERR_PACKET:
+		return nil, parseErrPacket(data)
+	case LOCAL_INFILE:
+		// refuse LOAD DATA LOCAL INFILE by sending an empty packet
+		err = c.writePacket([]byte{})
+		if err != nil {
+			return nil, err
+		}
+		_, err = c.readOkPacket()
+		if err != nil {
+			return nil, err
+		}
+		return nil, &errorMysql{"LOAD DATA LOCAL INFILE is not supported"}
+	}
+
+	r := &packetReader{data: data}
+	count, _, err := r.lenEncInt()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	result.Columns = make([]Column, 0, count)
+	for i := uint64(0); i < count; i++ {
+		data, err = c.readPacket()
+		if err != nil {
+			return nil, err
+		}
+		column, err := parseColumnDefinition(data)
+		if err != nil {
+			return nil, err
+		}
+		result.Columns = append(result.Columns, *column)
+	}
+
+	// column definitions are terminated by EOF_Packet
+	data, err = c.readPacket()
+	if err != nil {
+		return nil, err
+	}
+	if !isEOFPacket(data) {
+		return nil, &errorMysql{"expected EOF packet after column definitions"}
+	}
+
+	for {
+		data, err = c.readPacket()
+		if err != nil {
+			return nil, err
+		}
+		if isEOFPacket(data) {
+			r = &packetReader{data: data[1:]}
+			result.Warnings, _ = r.uint16()
+			result.Status, _ = r.uint16()
+			c.status = result.Status
+			return result, nil
+		}
+		if len(data) > 0 && data[0] == ERR_PACKET {
+			return nil, parseErrPacket(data)
+		}
+
+		r = &packetReader{data: data}
+		row := make([]Value, 0, count)
+		for i := uint64(0); i < count; i++ {
+			value, null, err := r.lenEncString()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, Value{Data: value, Null: null})
+		}
+		result.Rows = append(result.Rows, row)
+	}
+}
+
+// parseOkPacket parses payload of an OK_Packet.
+func (c *Conn) parseOkPacket(data []byte) (*Result, error) {
+	var err error
+	result := &Result{}
+	r := &packetReader{data: data[1:]}
+
+	if result.AffectedRows, _, err = r.lenEncInt(); err != nil {
+		return nil, err
+	}
+	if result.LastInsertId, _, err = r.lenEncInt(); err != nil {
+		return nil, err
+	}
+	if result.Status, err = r.uint16(); err != nil {
+		return nil, err
+	}
+	if result.Warnings, err = r.uint16(); err != nil {
+		return nil, err
+	}
+	result.Info = string(r.rest())
+	c.status = result.Status
+
+	return result, nil
+}
+
+// parseColumnDefinition parses payload of Protocol::ColumnDefinition41.
+func parseColumnDefinition(data []byte) (*Column, error) {
+	var err error
+	column := &Column{}
+	r := &packetReader{data: data}
+
+	// catalog is always "def"
+	if _, _, err = r.lenEncString(); err != nil {
+		return nil, err
+	}
+	if column.Schema, _, err = r.lenEncString(); err != nil {
+		return nil, err
+	}
+	if column.Table, _, err = r.lenEncString(); err != nil {
+		return nil, err
+	}
+	if column.OrgTable, _, err = r.lenEncString(); err != nil {
+		return nil, err
+	}
+	if column.Name, _, err = r.lenEncString(); err != nil {
+		return nil, err
+	}
+	if column.OrgName, _, err = r.lenEncString(); err != nil {
+		return nil, err
+	}
+	// length of fixed-length fields is always 0x0c
+	if _, _, err = r.lenEncInt(); err != nil {
+		return nil, err
+	}
+	if column.Charset, err = r.uint16(); err != nil {
+		return nil, err
+	}
+	if column.Length, err = r.uint32(); err != nil {
+		return nil, err
+	}
+	if column.Type, err = r.byte(); err != nil {
+		return nil, err
+	}
+	if column.Flags, err = r.uint16(); err != nil {
+		return nil, err
+	}
+	if column.Decimals, err = r.byte(); err != nil {
+		return nil, err
+	}
+
+	return column, nil
+}
+
+// isEOFPacket returns true if the given payload is EOF_Packet.
+func isEOFPacket(data []byte) bool {
+	return len(data) > 0 && len(data) < 9 && data[0] == EOF_PACKET
+}
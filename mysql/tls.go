@@ -0,0 +1,167 @@
+// mysql package implements the client side of the MySQL client/server
+// protocol.
+package mysql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"strings"
+)
+
+// SSLMode describes the security state of the connection to the server.
+type SSLMode int
+
+// SSL modes which are supported by the client. The zero value is
+// SSL_MODE_PREFERRED which is the default mode of the mysql client.
+const (
+	SSL_MODE_PREFERRED       SSLMode = iota // use encryption if the server supports it
+	SSL_MODE_DISABLED                       // use unencrypted connection
+	SSL_MODE_REQUIRED                       // fail if encrypted connection can't be established
+	SSL_MODE_VERIFY_CA                      // like REQUIRED and verify the server certificate against the CA
+	SSL_MODE_VERIFY_IDENTITY                // like VERIFY_CA and verify the server host name
+)
+
+var sslModeNames = map[string]SSLMode{
+	"PREFERRED":       SSL_MODE_PREFERRED,
+	"DISABLED":        SSL_MODE_DISABLED,
+	"REQUIRED":        SSL_MODE_REQUIRED,
+	"VERIFY_CA":       SSL_MODE_VERIFY_CA,
+	"VERIFY_IDENTITY": SSL_MODE_VERIFY_IDENTITY,
+}
+
+var tlsVersionNames = map[string]uint16{
+	"TLSv1":   tls.VersionTLS10,
+	"TLSv1.1": tls.VersionTLS11,
+	"TLSv1.2": tls.VersionTLS12,
+	"TLSv1.3": tls.VersionTLS13,
+}
+
+// ParseSSLMode parses value of the --ssl-mode option. The value is
+// case insensitive.
+func ParseSSLMode(mode string) (SSLMode, error) {
+	sslMode, ok := sslModeNames[strings.ToUpper(mode)]
+	if !ok {
+		return 0, &errorMysql{"unknown SSL mode '" + mode + "'"}
+	}
+	return sslMode, nil
+}
+
+// tlsConfig builds configuration of TLS for the connection from
+// the SSL related fields of the Config.
+func (config *Config) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if config.SSLCA != "" {
+		pem, err := ioutil.ReadFile(config.SSLCA)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, &errorMysql{"no certificates found in " + config.SSLCA}
+		}
+	}
+
+	if config.SSLCert != "" || config.SSLKey != "" {
+		certificate, err := tls.LoadX509KeyPair(config.SSLCert, config.SSLKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if config.TLSVersion != "" {
+		for _, name := range strings.Split(config.TLSVersion, ",") {
+			version, ok := tlsVersionNames[strings.TrimSpace(name)]
+			if !ok {
+				return nil, &errorMysql{"unknown TLS version '" + name + "'"}
+			}
+			if tlsConfig.MinVersion == 0 || version < tlsConfig.MinVersion {
+				tlsConfig.MinVersion = version
+			}
+			if version > tlsConfig.MaxVersion {
+				tlsConfig.MaxVersion = version
+			}
+		}
+	}
+
+	switch config.SSLMode {
+	case SSL_MODE_VERIFY_IDENTITY:
+		host, _, err := net.SplitHostPort(config.Address)
+		if err != nil {
+			host = config.Address
+		}
+		tlsConfig.ServerName = host
+	case SSL_MODE_VERIFY_CA:
+		// the chain is verified by verifyCA, but the host name
+		// is not checked
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyCA(tlsConfig.RootCAs)
+	default:
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return tlsConfig, nil
+}
+
+// verifyCA returns function which verifies the certificate chain of
+// the server without verification of the host name.
+func verifyCA(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, cert)
+		}
+		if len(certs) == 0 {
+			return &errorMysql{"the server did not provide a certificate"}
+		}
+
+		options := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+		for _, cert := range certs[1:] {
+			options.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(options)
+		return err
+	}
+}
+
+// startTLS sends SSLRequest packet to the server and switches the
+// connection to TLS.
+func (c *Conn) startTLS() error {
+	tlsConfig, err := c.config.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	err = c.writePacket(c.handshakeResponseHeader())
+	if err != nil {
+		return err
+	}
+
+	tlsConn := tls.Client(c.netConn, tlsConfig)
+	err = tlsConn.Handshake()
+	if err != nil {
+		return err
+	}
+	c.netConn = tlsConn
+	c.reader.Reset(tlsConn)
+	c.tls = true
+
+	return nil
+}
+
+// TLSConnectionState returns state of the TLS connection. The second
+// returned value is false if the connection is not encrypted.
+func (c *Conn) TLSConnectionState() (tls.ConnectionState, bool) {
+	tlsConn, ok := c.netConn.(*tls.Conn)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+	return tlsConn.ConnectionState(), true
+}
@@ -0,0 +1,288 @@
+// options package provides parser of command line options which is
+// compatible with the options parser of the MySQL client programs.
+package options
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maximum depth of nested !include and !includedir directives
+const maxIncludeDepth = 10
+
+// enviroment variable with path to the directory with my.cnf
+const mysqlHomeEnv = "MYSQL_HOME"
+
+// global option files in the order they are read
+var globalDefaultsFiles = []string{
+	"/etc/my.cnf",
+	"/etc/mysql/my.cnf",
+}
+
+// FileOption is an option read from an option file.
+type FileOption struct {
+	Group    string // group where the option is defined
+	Name     string // name of the option
+	Value    string // value of the option
+	HasValue bool   // false if the option is given without a value
+}
+
+// DefaultsFiles returns the option files which are read by the MySQL
+// client programs in the order they are read:
+//
+//   - /etc/my.cnf
+//   - /etc/mysql/my.cnf
+//   - $MYSQL_HOME/my.cnf
+//   - the file given by --defaults-extra-file
+//   - ~/.my.cnf
+//
+// If the defaultsFile (--defaults-file) is not empty, only this file
+// is read.
+func DefaultsFiles(defaultsFile string, extraFile string) []string {
+	if defaultsFile != "" {
+		return []string{defaultsFile}
+	}
+
+	files := []string{}
+	files = append(files, globalDefaultsFiles...)
+	if home := os.Getenv(mysqlHomeEnv); home != "" {
+		files = append(files, filepath.Join(home, "my.cnf"))
+	}
+	if extraFile != "" {
+		files = append(files, extraFile)
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		files = append(files, filepath.Join(home, ".my.cnf"))
+	}
+	return files
+}
+
+// DefaultsGroups returns the given groups followed by the same groups
+// with the suffix given by --defaults-group-suffix.
+func DefaultsGroups(groups []string, suffix string) []string {
+	result := append([]string{}, groups...)
+	if suffix != "" {
+		for _, group := range groups {
+			result = append(result, group+suffix)
+		}
+	}
+	return result
+}
+
+// ReadDefaults reads the given option files and sets the values of the
+// options from the given groups. Files which do not exist are skipped.
+// Options which are unknown to the set are ignored because the groups
+// like [client] are shared between different programs.
+func (s *OptionSet) ReadDefaults(files []string, groups []string) error {
+	for _, file := range files {
+		fileOptions, err := ReadOptionFile(file, groups)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		err = s.setFileOptions(fileOptions, file)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFileOptions sets the values of the options read from the file.
+func (s *OptionSet) setFileOptions(fileOptions []FileOption, file string) error {
+	for _, option := range fileOptions {
+		arg := option.Name
+		if !strings.HasPrefix(arg, "loose-") {
+			arg = "loose-" + arg
+		}
+		if option.HasValue {
+			arg += "=" + option.Value
+		}
+		_, err := s.parseLong(arg, nil)
+		if err != nil {
+			return &errorOptions{err.Error() + " in " + file}
+		}
+	}
+	return nil
+}
+
+// ReadOptionFile reads an option file and returns the options from the
+// given groups in the order they are defined. It handles !include and
+// !includedir directives.
+func ReadOptionFile(path string, groups []string) ([]FileOption, error) {
+	return readOptionFile(path, groupSet(groups), 0)
+}
+
+// groupSet returns set of lower-cased group names.
+func groupSet(groups []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, group := range groups {
+		set[strings.ToLower(group)] = true
+	}
+	return set
+}
+
+func readOptionFile(path string, groups map[string]bool, depth int) ([]FileOption, error) {
+	if depth > maxIncludeDepth {
+		return nil, &errorOptions{"too many nested includes in " + path}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseOptionFile(file, path, groups, depth)
+}
+
+// parseOptionFile parses content of an option file with the given path.
+func parseOptionFile(file io.Reader, path string, groups map[string]bool, depth int) ([]FileOption, error) {
+	result := []FileOption{}
+	group := ""
+	lineNumber := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case strings.HasPrefix(line, "!includedir"):
+			dir := strings.TrimSpace(line[len("!includedir"):])
+			included, err := readOptionDir(dir, groups, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, included...)
+		case strings.HasPrefix(line, "!include"):
+			included, err := readOptionFile(strings.TrimSpace(line[len("!include"):]), groups, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, included...)
+		case line[0] == '[':
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, &errorOptions{"wrong group definition in " + path + " at line " + strconv.Itoa(lineNumber)}
+			}
+			group = strings.ToLower(strings.TrimSpace(line[1:end]))
+		default:
+			if group == "" {
+				return nil, &errorOptions{"found option without preceding group in " + path + " at line " + strconv.Itoa(lineNumber)}
+			}
+			if !groups[group] {
+				continue
+			}
+			option := parseOptionLine(line)
+			option.Group = group
+			result = append(result, option)
+		}
+	}
+
+	return result, scanner.Err()
+}
+
+// readOptionDir reads all *.cnf files from the directory in the
+// alphabetical order.
+func readOptionDir(dir string, groups map[string]bool, depth int) ([]FileOption, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".cnf") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	result := []FileOption{}
+	for _, name := range names {
+		options, err := readOptionFile(filepath.Join(dir, name), groups, depth)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, options...)
+	}
+	return result, nil
+}
+
+// parseOptionLine parses "name", "name=value" or "name = value" line
+// of an option file. The value may be quoted and may be followed by
+// a comment.
+func parseOptionLine(line string) FileOption {
+	option := FileOption{}
+	eq := strings.IndexByte(line, '=')
+	if eq < 0 {
+		option.Name = strings.TrimSpace(stripComment(line))
+		return option
+	}
+
+	option.Name = strings.TrimSpace(line[:eq])
+	option.HasValue = true
+	value := strings.TrimSpace(line[eq+1:])
+
+	if len(value) > 0 && (value[0] == '\'' || value[0] == '"') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			option.Value = unescapeValue(value[1 : end+1])
+			return option
+		}
+	}
+	option.Value = unescapeValue(strings.TrimSpace(stripComment(value)))
+	return option
+}
+
+// stripComment removes the "#" comment from the end of a line.
+func stripComment(s string) string {
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// unescapeValue handles escape sequences which are allowed in option
+// values: \b, \t, \n, \r, \\, \s. A backslash followed by other
+// character is kept as is.
+func unescapeValue(value string) string {
+	if strings.IndexByte(value, '\\') < 0 {
+		return value
+	}
+
+	result := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			result = append(result, value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'b':
+			result = append(result, '\b')
+		case 't':
+			result = append(result, '\t')
+		case 'n':
+			result = append(result, '\n')
+		case 'r':
+			result = append(result, '\r')
+		case '\\':
+			result = append(result, '\\')
+		case 's':
+			result = append(result, ' ')
+		default:
+			result = append(result, '\\', value[i])
+		}
+	}
+	return string(result)
+}
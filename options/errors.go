@@ -0,0 +1,13 @@
+// options package provides parser of command line options which is
+// compatible with the options parser of the MySQL client programs.
+package options
+
+// errorOptions is an implementation of error interface which
+// provides error message for the options parser.
+type errorOptions struct {
+	errMsg string
+}
+
+func (err errorOptions) Error() string {
+	return err.errMsg
+}
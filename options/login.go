@@ -0,0 +1,106 @@
+// options package provides parser of command line options which is
+// compatible with the options parser of the MySQL client programs.
+package options
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// layout of the login path file created by mysql_config_editor
+const (
+	loginFileUnusedLength = 4  // unused bytes at the beginning of the file
+	loginFileKeyLength    = 20 // length of the obfuscated key
+	loginFileHeaderLength = loginFileUnusedLength + loginFileKeyLength
+	loginFileCipherLength = 4 // length of a cipher length prefix
+)
+
+// enviroment variable which overrides path to the login path file
+const loginFileEnv = "MYSQL_TEST_LOGIN_FILE"
+
+// LoginPathFile returns path to the login path file: $MYSQL_TEST_LOGIN_FILE
+// or ~/.mylogin.cnf.
+func LoginPathFile() string {
+	if path := os.Getenv(loginFileEnv); path != "" {
+		return path
+	}
+	return filepath.Join(os.Getenv("HOME"), ".mylogin.cnf")
+}
+
+// DecryptLoginFile decrypts content of the login path file. The file
+// starts with 4 unused bytes and 20 bytes of the key which are folded
+// to the 128-bit AES key. Each line of the file is encrypted with
+// AES-128-ECB separately and prefixed with the length of the cipher.
+func DecryptLoginFile(data []byte) ([]byte, error) {
+	if len(data) < loginFileHeaderLength {
+		return nil, &errorOptions{"login path file is too short"}
+	}
+
+	key := make([]byte, aes.BlockSize)
+	for i, b := range data[loginFileUnusedLength:loginFileHeaderLength] {
+		key[i%aes.BlockSize] ^= b
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := &bytes.Buffer{}
+	data = data[loginFileHeaderLength:]
+	for len(data) > 0 {
+		if len(data) < loginFileCipherLength {
+			return nil, &errorOptions{"login path file is corrupted"}
+		}
+		length := int(binary.LittleEndian.Uint32(data))
+		data = data[loginFileCipherLength:]
+		if length == 0 || length%aes.BlockSize != 0 || length > len(data) {
+			return nil, &errorOptions{"login path file is corrupted"}
+		}
+
+		line := make([]byte, length)
+		for i := 0; i < length; i += aes.BlockSize {
+			block.Decrypt(line[i:i+aes.BlockSize], data[i:i+aes.BlockSize])
+		}
+		data = data[length:]
+
+		// remove PKCS #7 padding
+		padding := int(line[length-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, &errorOptions{"login path file is corrupted"}
+		}
+		plain.Write(line[:length-padding])
+	}
+	return plain.Bytes(), nil
+}
+
+// ReadLoginPathFile reads the login path file and returns the options
+// from the given groups. Login paths are groups of the file.
+func ReadLoginPathFile(path string, groups []string) ([]FileOption, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := DecryptLoginFile(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseOptionFile(bytes.NewReader(plain), path, groupSet(groups), maxIncludeDepth)
+}
+
+// ReadLoginPath reads the login path file and sets the values of the
+// options from the given groups. The file is skipped if it does not
+// exist.
+func (s *OptionSet) ReadLoginPath(path string, groups []string) error {
+	fileOptions, err := ReadLoginPathFile(path, groups)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return s.setFileOptions(fileOptions, path)
+}
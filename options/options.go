@@ -0,0 +1,356 @@
+// options package provides parser of command line options which is
+// compatible with the options parser of the MySQL client programs:
+//
+//   - long options: --user=root, --user root, --skip-auto-rehash;
+//   - short options: -u root, -uroot, -pSECRET, -vvv;
+//   - options with optional value: --password, -p;
+//   - unambiguous prefixes of long options: --data=test;
+//   - dashes and underscores are interchangeable in names.
+package options
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// kinds of an option argument
+const (
+	noArgument       = iota // the option is a boolean switch
+	requiredArgument        // the option requires a value
+	optionalArgument        // the option may be given without a value
+)
+
+// Value is the interface to the value stored in an option.
+type Value interface {
+	String() string
+	Set(string) error
+}
+
+// Option describes a single option.
+type Option struct {
+	Name      string // long name of the option, e.g. "database"
+	Short     byte   // short name of the option, e.g. 'D', or 0
+	Usage     string // help message
+	Value     Value  // value of the option
+	Given     bool   // true if the option was set
+	argument  int
+	valueName string
+}
+
+// OptionSet is a set of defined options.
+type OptionSet struct {
+	name    string
+	options []*Option
+	long    map[string]*Option
+	short   map[byte]*Option
+	args    []string
+}
+
+// NewOptionSet returns new empty set of options for the program with
+// the given name.
+func NewOptionSet(name string) *OptionSet {
+	set := &OptionSet{}
+	set.name = name
+	set.long = make(map[string]*Option)
+	set.short = make(map[byte]*Option)
+	return set
+}
+
+// normalizeName replaces underscores in an option name by dashes.
+func normalizeName(name string) string {
+	return strings.Replace(name, "_", "-", -1)
+}
+
+// define adds new option to the set.
+func (s *OptionSet) define(name string, short byte, usage string, value Value, argument int, valueName string) *Option {
+	name = normalizeName(name)
+	if _, ok := s.long[name]; ok {
+		panic("options: option redefined: " + name)
+	}
+	option := &Option{Name: name, Short: short, Usage: usage, Value: value,
+		argument: argument, valueName: valueName}
+	s.options = append(s.options, option)
+	s.long[name] = option
+	if short != 0 {
+		s.short[short] = option
+	}
+	return option
+}
+
+// String defines an option with a string value.
+func (s *OptionSet) String(name string, short byte, value string, usage string) *string {
+	p := new(string)
+	*p = value
+	s.define(name, short, usage, (*stringValue)(p), requiredArgument, "name")
+	return p
+}
+
+// Int defines an option with an integer value.
+func (s *OptionSet) Int(name string, short byte, value int, usage string) *int {
+	p := new(int)
+	*p = value
+	s.define(name, short, usage, (*intValue)(p), requiredArgument, "#")
+	return p
+}
+
+// Bool defines a boolean option. It can be disabled with --skip-name
+// or --disable-name and set explicitly with --name=0 or --name=1.
+func (s *OptionSet) Bool(name string, short byte, value bool, usage string) *bool {
+	p := new(bool)
+	*p = value
+	s.define(name, short, usage, (*boolValue)(p), noArgument, "")
+	return p
+}
+
+// OptionalString defines an option whose value may be omitted,
+// like --password of the mysql client.
+func (s *OptionSet) OptionalString(name string, short byte, usage string) *OptionalString {
+	p := &OptionalString{}
+	s.define(name, short, usage, p, optionalArgument, "name")
+	return p
+}
+
+// Lookup returns the option with the given long name or nil.
+func (s *OptionSet) Lookup(name string) *Option {
+	return s.long[normalizeName(name)]
+}
+
+// IsSet returns true if the option with the given name was set.
+func (s *OptionSet) IsSet(name string) bool {
+	option := s.Lookup(name)
+	return option != nil && option.Given
+}
+
+// Args returns the non-option arguments.
+func (s *OptionSet) Args() []string {
+	return s.args
+}
+
+// Parse parses the command line arguments without the program name.
+// Options and non-option arguments may be mixed, "--" terminates
+// the options.
+func (s *OptionSet) Parse(arguments []string) error {
+	s.args = nil
+	for i := 0; i < len(arguments); i++ {
+		arg := arguments[i]
+		switch {
+		case arg == "--":
+			s.args = append(s.args, arguments[i+1:]...)
+			return nil
+		case strings.HasPrefix(arg, "--"):
+			next, err := s.parseLong(arg[2:], arguments[i+1:])
+			if err != nil {
+				return err
+			}
+			i += next
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			next, err := s.parseShort(arg[1:], arguments[i+1:])
+			if err != nil {
+				return err
+			}
+			i += next
+		default:
+			s.args = append(s.args, arg)
+		}
+	}
+	return nil
+}
+
+// parseLong parses a long option. It returns the number of consumed
+// arguments which follow the option.
+func (s *OptionSet) parseLong(arg string, rest []string) (int, error) {
+	name, value, hasValue := arg, "", false
+	if eq := strings.IndexByte(arg, '='); eq >= 0 {
+		name, value, hasValue = arg[:eq], arg[eq+1:], true
+	}
+	name = normalizeName(name)
+
+	loose := false
+	if strings.HasPrefix(name, "loose-") {
+		name = name[len("loose-"):]
+		loose = true
+	}
+
+	option, enable, err := s.find(name)
+	if err != nil {
+		if loose {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	switch option.argument {
+	case noArgument:
+		if !hasValue {
+			return 0, s.setBool(option, enable)
+		}
+		b, err := parseBool(value)
+		if err != nil {
+			return 0, &errorOptions{"option '--" + option.Name + "' requires boolean value"}
+		}
+		return 0, s.setBool(option, b == enable)
+	case requiredArgument:
+		if !enable {
+			return 0, &errorOptions{"option '--" + name + "' can't be disabled"}
+		}
+		if hasValue {
+			return 0, s.set(option, value)
+		}
+		if len(rest) == 0 {
+			return 0, &errorOptions{"option '--" + option.Name + "' requires an argument"}
+		}
+		return 1, s.set(option, rest[0])
+	default:
+		// --skip-password means the empty password
+		if !enable {
+			return 0, s.set(option, "")
+		}
+		if hasValue {
+			return 0, s.set(option, value)
+		}
+		return 0, s.setNoValue(option)
+	}
+}
+
+// parseShort parses a group of short options. It returns the number of
+// consumed arguments which follow the group.
+func (s *OptionSet) parseShort(arg string, rest []string) (int, error) {
+	for i := 0; i < len(arg); i++ {
+		option, ok := s.short[arg[i]]
+		if !ok {
+			return 0, &errorOptions{"unknown option '-" + string(arg[i]) + "'"}
+		}
+
+		switch option.argument {
+		case noArgument:
+			err := s.setBool(option, true)
+			if err != nil {
+				return 0, err
+			}
+		case requiredArgument:
+			if i+1 < len(arg) {
+				return 0, s.set(option, arg[i+1:])
+			}
+			if len(rest) == 0 {
+				return 0, &errorOptions{"option '-" + string(arg[i]) + "' requires an argument"}
+			}
+			return 1, s.set(option, rest[0])
+		default:
+			// the value of an optional argument must be glued
+			// to the option
+			if i+1 < len(arg) {
+				return 0, s.set(option, arg[i+1:])
+			}
+			return 0, s.setNoValue(option)
+		}
+	}
+	return 0, nil
+}
+
+// find looks for an option by its name, a --skip-, --disable- or
+// --enable- prefixed name of a boolean option or an unambiguous prefix
+// of a name. The second returned value is false if the option is
+// disabled.
+func (s *OptionSet) find(name string) (*Option, bool, error) {
+	if option, ok := s.long[name]; ok {
+		return option, true, nil
+	}
+
+	for _, prefix := range []string{"skip-", "disable-", "enable-"} {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		option, _, err := s.find(name[len(prefix):])
+		if err != nil {
+			continue
+		}
+		return option, prefix == "enable-", nil
+	}
+
+	var found *Option
+	for _, option := range s.options {
+		if strings.HasPrefix(option.Name, name) {
+			if found != nil {
+				return nil, false, &errorOptions{"ambiguous option '--" + name + "' (" +
+					found.Name + ", " + option.Name + ")"}
+			}
+			found = option
+		}
+	}
+	if found == nil {
+		return nil, false, &errorOptions{"unknown option '--" + name + "'"}
+	}
+	return found, true, nil
+}
+
+// Set sets value of the option with the given name. The name may be
+// prefixed with skip-, disable-, enable- or loose- like on the command
+// line. The empty value of a boolean option means true.
+func (s *OptionSet) Set(name string, value string) error {
+	arg := name
+	if value != "" {
+		arg += "=" + value
+	}
+	_, err := s.parseLong(arg, nil)
+	return err
+}
+
+func (s *OptionSet) set(option *Option, value string) error {
+	err := option.Value.Set(value)
+	if err != nil {
+		return &errorOptions{"invalid value '" + value + "' for option '--" + option.Name + "'"}
+	}
+	option.Given = true
+	return nil
+}
+
+func (s *OptionSet) setBool(option *Option, value bool) error {
+	return s.set(option, strconv.FormatBool(value))
+}
+
+func (s *OptionSet) setNoValue(option *Option) error {
+	option.Value.(*OptionalString).setNoValue()
+	option.Given = true
+	return nil
+}
+
+// PrintUsage prints help messages of all options sorted by long name.
+func (s *OptionSet) PrintUsage(w io.Writer) {
+	options := make([]*Option, len(s.options))
+	copy(options, s.options)
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].Name < options[j].Name
+	})
+
+	for _, option := range options {
+		line := "  "
+		if option.Short != 0 {
+			line += "-" + string(option.Short) + ", "
+		} else {
+			line += "    "
+		}
+		line += "--" + option.Name
+		switch option.argument {
+		case requiredArgument:
+			line += "=" + option.valueName
+		case optionalArgument:
+			line += "[=" + option.valueName + "]"
+		}
+		fmt.Fprintln(w, line)
+		fmt.Fprintln(w, "        "+option.Usage)
+	}
+}
+
+// parseBool parses value of a boolean option like MySQL does it.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "on", "true", "yes":
+		return true, nil
+	case "0", "off", "false", "no":
+		return false, nil
+	}
+	return false, &errorOptions{"invalid boolean value '" + value + "'"}
+}
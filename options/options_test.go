@@ -0,0 +1,379 @@
+// options package provides parser of command line options which is
+// compatible with the options parser of the MySQL client programs.
+package options
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testOptions struct {
+	set      *OptionSet
+	user     *string
+	database *string
+	port     *int
+	password *OptionalString
+	verbose  *bool
+	rehash   *bool
+	sslMode  *string
+	sslCA    *string
+}
+
+func newTestOptions() *testOptions {
+	o := &testOptions{set: NewOptionSet("mysql")}
+	o.user = o.set.String("user", 'u', "", "User for login.")
+	o.database = o.set.String("database", 'D', "", "Database to use.")
+	o.port = o.set.Int("port", 'P', 0, "Port number.")
+	o.password = o.set.OptionalString("password", 'p', "Password to use.")
+	o.verbose = o.set.Bool("verbose", 'v', false, "Write more.")
+	o.rehash = o.set.Bool("auto-rehash", 0, true, "Enable automatic rehashing.")
+	o.sslMode = o.set.String("ssl-mode", 0, "", "SSL mode.")
+	o.sslCA = o.set.String("ssl-ca", 0, "", "CA file.")
+	return o
+}
+
+func TestParseLong(t *testing.T) {
+	o := newTestOptions()
+	err := o.set.Parse([]string{"--user=root", "--database", "test", "--port=3307", "--skip-auto-rehash", "--ssl_mode=REQUIRED"})
+	if err != nil {
+		t.Fatal("Parse failed", err)
+	}
+	if *o.user != "root" || *o.database != "test" || *o.port != 3307 {
+		t.Error("Parse failed (values)")
+	}
+	if *o.rehash != false {
+		t.Error("Parse failed (--skip-auto-rehash)")
+	}
+	if *o.sslMode != "REQUIRED" {
+		t.Error("Parse failed (underscore in name)")
+	}
+	if !o.set.IsSet("user") || o.set.IsSet("verbose") {
+		t.Error("IsSet failed")
+	}
+
+	o = newTestOptions()
+	err = o.set.Parse([]string{"--auto-rehash=0", "--enable-verbose", "--data=db"})
+	if err != nil || *o.rehash != false || *o.verbose != true || *o.database != "db" {
+		t.Error("Parse failed (--name=0, --enable-name, prefix)")
+	}
+}
+
+func TestParseShort(t *testing.T) {
+	o := newTestOptions()
+	err := o.set.Parse([]string{"-uroot", "-D", "test", "-P3307", "-vv", "-psecret"})
+	if err != nil {
+		t.Fatal("Parse failed", err)
+	}
+	if *o.user != "root" || *o.database != "test" || *o.port != 3307 || !*o.verbose {
+		t.Error("Parse failed (short values)")
+	}
+	if o.password.Value != "secret" || o.password.NoValue {
+		t.Error("Parse failed (-pSECRET)")
+	}
+
+	o = newTestOptions()
+	err = o.set.Parse([]string{"-vu", "root"})
+	if err != nil || !*o.verbose || *o.user != "root" {
+		t.Error("Parse failed (grouped short options)")
+	}
+}
+
+func TestParsePassword(t *testing.T) {
+	// the value of -p must be glued, the next argument is the database
+	o := newTestOptions()
+	err := o.set.Parse([]string{"-u", "root", "-p", "test"})
+	if err != nil {
+		t.Fatal("Parse failed", err)
+	}
+	if !o.password.NoValue || !o.set.IsSet("password") {
+		t.Error("Parse failed (-p without value)")
+	}
+	if len(o.set.Args()) != 1 || o.set.Args()[0] != "test" {
+		t.Error("Parse failed (positional argument)")
+	}
+
+	o = newTestOptions()
+	err = o.set.Parse([]string{"--password"})
+	if err != nil || !o.password.NoValue {
+		t.Error("Parse failed (--password without value)")
+	}
+
+	o = newTestOptions()
+	err = o.set.Parse([]string{"--password="})
+	if err != nil || o.password.NoValue || o.password.Value != "" || !o.set.IsSet("password") {
+		t.Error("Parse failed (--password=)")
+	}
+
+	o = newTestOptions()
+	err = o.set.Parse([]string{"--password=secret"})
+	if err != nil || o.password.NoValue || o.password.Value != "secret" {
+		t.Error("Parse failed (--password=secret)")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"--unknown"},
+		{"-x"},
+		{"--user"},
+		{"-u"},
+		{"--port=abc"},
+		{"--verbose=maybe"},
+		{"--ssl=1"},
+		{"--skip-user"},
+	} {
+		o := newTestOptions()
+		if o.set.Parse(args) == nil {
+			t.Error("Parse must fail", args)
+		}
+	}
+
+	// unknown loose options are ignored
+	o := newTestOptions()
+	if o.set.Parse([]string{"--loose-unknown=1", "--loose-user=root"}) != nil || *o.user != "root" {
+		t.Error("Parse failed (--loose-)")
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	o := newTestOptions()
+	err := o.set.Parse([]string{"test", "-uroot", "--", "-v"})
+	if err != nil {
+		t.Fatal("Parse failed", err)
+	}
+	args := o.set.Args()
+	if len(args) != 2 || args[0] != "test" || args[1] != "-v" || *o.verbose {
+		t.Error("Parse failed (arguments)", args)
+	}
+}
+
+func TestSet(t *testing.T) {
+	o := newTestOptions()
+	if o.set.Set("user", "admin") != nil || *o.user != "admin" {
+		t.Error("Set failed")
+	}
+	if o.set.Set("skip-auto-rehash", "") != nil || *o.rehash {
+		t.Error("Set failed (skip-)")
+	}
+	if o.set.Set("verbose", "") != nil || !*o.verbose {
+		t.Error("Set failed (boolean without value)")
+	}
+}
+
+func TestPrintUsage(t *testing.T) {
+	o := newTestOptions()
+	buf := &bytes.Buffer{}
+	o.set.PrintUsage(buf)
+	usage := buf.String()
+	if !strings.Contains(usage, "  -u, --user=name\n        User for login.\n") {
+		t.Error("PrintUsage failed (--user)")
+	}
+	if !strings.Contains(usage, "  -p, --password[=name]\n") {
+		t.Error("PrintUsage failed (--password)")
+	}
+	if !strings.Contains(usage, "      --auto-rehash\n") {
+		t.Error("PrintUsage failed (--auto-rehash)")
+	}
+}
+
+// writeFile writes the content to the file in the directory and returns
+// path to the file.
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal("WriteFile failed")
+	}
+	return path
+}
+
+func TestReadOptionFile(t *testing.T) {
+	dir := t.TempDir()
+	confd := filepath.Join(dir, "conf.d")
+	os.Mkdir(confd, 0700)
+	writeFile(t, confd, "b.cnf", "[mysql]\nport=3308\n")
+	writeFile(t, confd, "a.cnf", "[mysql]\nport=3307\n")
+	writeFile(t, confd, "ignored.txt", "[mysql]\nport=1\n")
+	writeFile(t, dir, "extra.cnf", "[client]\nssl-ca=/etc/ca.pem\n")
+	path := writeFile(t, dir, "my.cnf", `
+# comment
+; another comment
+[client]
+user = root
+password="se#cret"
+host=db.local   # trailing comment
+
+[mysqld]
+port=3306
+
+[mysql]
+skip-auto-rehash
+database='my db'
+prompt=\\u@\\h\s>\n
+!include `+filepath.Join(dir, "extra.cnf")+`
+!includedir `+confd+`
+
+[MySQL_Test]
+verbose
+`)
+
+	options, err := ReadOptionFile(path, DefaultsGroups([]string{"client", "mysql"}, "_test"))
+	if err != nil {
+		t.Fatal("ReadOptionFile failed", err)
+	}
+
+	expected := []FileOption{
+		{"client", "user", "root", true},
+		{"client", "password", "se#cret", true},
+		{"client", "host", "db.local", true},
+		{"mysql", "skip-auto-rehash", "", false},
+		{"mysql", "database", "my db", true},
+		{"mysql", "prompt", "\\u@\\h >\n", true},
+		{"client", "ssl-ca", "/etc/ca.pem", true},
+		{"mysql", "port", "3307", true},
+		{"mysql", "port", "3308", true},
+		{"mysql_test", "verbose", "", false},
+	}
+	if len(options) != len(expected) {
+		t.Fatal("ReadOptionFile failed (number of options)", options)
+	}
+	for i := range expected {
+		if options[i] != expected[i] {
+			t.Error("ReadOptionFile failed", options[i], expected[i])
+		}
+	}
+}
+
+func TestReadOptionFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "no-group.cnf", "user=root\n")
+	_, err := ReadOptionFile(path, []string{"client"})
+	if err == nil {
+		t.Error("ReadOptionFile must fail on option without group")
+	}
+
+	path = writeFile(t, dir, "loop.cnf", "!include "+filepath.Join(dir, "loop.cnf")+"\n")
+	_, err = ReadOptionFile(path, []string{"client"})
+	if err == nil {
+		t.Error("ReadOptionFile must fail on recursive include")
+	}
+}
+
+func TestReadDefaults(t *testing.T) {
+	dir := t.TempDir()
+	global := writeFile(t, dir, "global.cnf", "[client]\nuser=global\nport=3307\nunknown-option=1\n")
+	user := writeFile(t, dir, "user.cnf", "[client]\nuser=local\n[mysql]\nskip-auto-rehash\npassword\n")
+
+	o := newTestOptions()
+	err := o.set.ReadDefaults([]string{global, filepath.Join(dir, "missing.cnf"), user}, []string{"client", "mysql"})
+	if err != nil {
+		t.Fatal("ReadDefaults failed", err)
+	}
+	if *o.user != "local" || *o.port != 3307 || *o.rehash {
+		t.Error("ReadDefaults failed (values)")
+	}
+	if !o.password.NoValue {
+		t.Error("ReadDefaults failed (password without value)")
+	}
+
+	// the command line overrides the option files
+	err = o.set.Parse([]string{"-uroot", "-psecret"})
+	if err != nil || *o.user != "root" || o.password.Value != "secret" || *o.port != 3307 {
+		t.Error("ReadDefaults failed (command line precedence)")
+	}
+
+	bad := writeFile(t, dir, "bad.cnf", "[client]\nport=abc\n")
+	err = o.set.ReadDefaults([]string{bad}, []string{"client"})
+	if err == nil {
+		t.Error("ReadDefaults must fail on invalid value")
+	}
+}
+
+func TestDefaultsFiles(t *testing.T) {
+	os.Setenv("HOME", "/home/test")
+	os.Setenv("MYSQL_HOME", "/opt/mysql")
+
+	files := DefaultsFiles("", "/tmp/extra.cnf")
+	expected := []string{"/etc/my.cnf", "/etc/mysql/my.cnf", "/opt/mysql/my.cnf", "/tmp/extra.cnf", "/home/test/.my.cnf"}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Error("DefaultsFiles failed", files)
+	}
+
+	files = DefaultsFiles("/tmp/only.cnf", "/tmp/extra.cnf")
+	if len(files) != 1 || files[0] != "/tmp/only.cnf" {
+		t.Error("DefaultsFiles failed (--defaults-file)", files)
+	}
+}
+
+func TestReadLoginPathFile(t *testing.T) {
+	options, err := ReadLoginPathFile("testdata/mylogin.cnf", []string{"client", "mysql", "remote"})
+	if err != nil {
+		t.Fatal("ReadLoginPathFile failed", err)
+	}
+
+	expected := []FileOption{
+		{"client", "user", "localuser", true},
+		{"client", "password", "s3cr3t", true},
+		{"client", "host", "localhost", true},
+		{"remote", "user", "remoteuser", true},
+		{"remote", "password", "p@ss word", true},
+		{"remote", "host", "db.example.com", true},
+		{"remote", "port", "3307", true},
+	}
+	if len(options) != len(expected) {
+		t.Fatal("ReadLoginPathFile failed (number of options)", options)
+	}
+	for i := range expected {
+		if options[i] != expected[i] {
+			t.Error("ReadLoginPathFile failed", options[i], expected[i])
+		}
+	}
+}
+
+func TestReadLoginPath(t *testing.T) {
+	o := newTestOptions()
+	err := o.set.ReadLoginPath("testdata/mylogin.cnf", []string{"client", "mysql", "remote"})
+	if err != nil {
+		t.Fatal("ReadLoginPath failed", err)
+	}
+	if *o.user != "remoteuser" || o.password.Value != "p@ss word" || *o.port != 3307 {
+		t.Error("ReadLoginPath failed (values)")
+	}
+
+	// the default login path is client
+	o = newTestOptions()
+	err = o.set.ReadLoginPath("testdata/mylogin.cnf", []string{"client", "mysql"})
+	if err != nil || *o.user != "localuser" || *o.port != 0 {
+		t.Error("ReadLoginPath failed (client)")
+	}
+
+	err = o.set.ReadLoginPath("testdata/missing.cnf", []string{"client"})
+	if err != nil {
+		t.Error("ReadLoginPath must skip missing file")
+	}
+}
+
+func TestDecryptLoginFile(t *testing.T) {
+	data, err := os.ReadFile("testdata/mylogin.cnf")
+	if err != nil {
+		t.Fatal("ReadFile failed")
+	}
+
+	_, err = DecryptLoginFile(data[:10])
+	if err == nil {
+		t.Error("DecryptLoginFile must fail on short file")
+	}
+
+	_, err = DecryptLoginFile(data[:len(data)-3])
+	if err == nil {
+		t.Error("DecryptLoginFile must fail on truncated file")
+	}
+
+	os.Setenv("MYSQL_TEST_LOGIN_FILE", "/tmp/test.mylogin.cnf")
+	if LoginPathFile() != "/tmp/test.mylogin.cnf" {
+		t.Error("LoginPathFile failed")
+	}
+}
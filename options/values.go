@@ -0,0 +1,68 @@
+// options package provides parser of command line options which is
+// compatible with the options parser of the MySQL client programs.
+package options
+
+import "strconv"
+
+type stringValue string
+
+func (v *stringValue) Set(value string) error {
+	*v = stringValue(value)
+	return nil
+}
+
+func (v *stringValue) String() string {
+	return string(*v)
+}
+
+type intValue int
+
+func (v *intValue) Set(value string) error {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*v = intValue(i)
+	return nil
+}
+
+func (v *intValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+type boolValue bool
+
+func (v *boolValue) Set(value string) error {
+	b, err := parseBool(value)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(bool(*v))
+}
+
+// OptionalString is a value of an option which may be given without
+// a value, e.g. --password.
+type OptionalString struct {
+	Value   string // value of the option
+	NoValue bool   // true if the option was given without a value
+}
+
+func (v *OptionalString) Set(value string) error {
+	v.Value = value
+	v.NoValue = false
+	return nil
+}
+
+func (v *OptionalString) String() string {
+	return v.Value
+}
+
+func (v *OptionalString) setNoValue() {
+	v.Value = ""
+	v.NoValue = true
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0xAX/mysql-tools/mysql"
+	"github.com/0xAX/mysql-tools/wcwidth"
+)
+
+// nullValue is text representation of NULL values
+const nullValue = "NULL"
+
+// formatResult returns text of a result of a statement in the mysql
+// format. The rows are printed in the table format or vertically
+// if the statement is terminated by \G.
+func formatResult(result *mysql.Result, vertical bool, elapsed time.Duration) string {
+	var out strings.Builder
+
+	if len(result.Columns) == 0 {
+		rows := "rows"
+		if result.AffectedRows == 1 {
+			rows = "row"
+		}
+		fmt.Fprintf(&out, "Query OK, %d %s affected%s (%s)\n", result.AffectedRows, rows,
+			formatWarnings(result.Warnings), formatElapsed(elapsed))
+		if result.Info != "" {
+			fmt.Fprintf(&out, "%s\n", result.Info)
+		}
+		out.WriteString("\n")
+		return out.String()
+	}
+
+	if len(result.Rows) == 0 {
+		fmt.Fprintf(&out, "Empty set%s (%s)\n\n", formatWarnings(result.Warnings), formatElapsed(elapsed))
+		return out.String()
+	}
+
+	if vertical {
+		formatVertical(&out, result)
+	} else {
+		formatTable(&out, result)
+	}
+
+	rows := "rows"
+	if len(result.Rows) == 1 {
+		rows = "row"
+	}
+	fmt.Fprintf(&out, "%d %s in set%s (%s)\n\n", len(result.Rows), rows,
+		formatWarnings(result.Warnings), formatElapsed(elapsed))
+	return out.String()
+}
+
+// formatTable writes the rows of the result as table:
+//
+//	+----+------+
+//	| id | name |
+//	+----+------+
+//	|  1 | abc  |
+//	+----+------+
+func formatTable(out *strings.Builder, result *mysql.Result) {
+	widths := make([]int, len(result.Columns))
+	for i, column := range result.Columns {
+		widths[i] = wcwidth.StringWidth(column.Name)
+	}
+	for _, row := range result.Rows {
+		for i, value := range row {
+			if width := wcwidth.StringWidth(valueText(value)); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	separator := "+"
+	for _, width := range widths {
+		separator += strings.Repeat("-", width+2) + "+"
+	}
+	separator += "\n"
+
+	out.WriteString(separator)
+	out.WriteString("|")
+	for i, column := range result.Columns {
+		fmt.Fprintf(out, " %s%s |", column.Name, padding(column.Name, widths[i]))
+	}
+	out.WriteString("\n")
+	out.WriteString(separator)
+	for _, row := range result.Rows {
+		out.WriteString("|")
+		for i, value := range row {
+			text := valueText(value)
+			if isNumericColumn(result.Columns[i]) {
+				fmt.Fprintf(out, " %s%s |", padding(text, widths[i]), text)
+			} else {
+				fmt.Fprintf(out, " %s%s |", text, padding(text, widths[i]))
+			}
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString(separator)
+}
+
+// formatVertical writes the rows of the result vertically:
+//
+//	*************************** 1. row ***************************
+//	  id: 1
+//	name: abc
+func formatVertical(out *strings.Builder, result *mysql.Result) {
+	width := 0
+	for _, column := range result.Columns {
+		if wcwidth.StringWidth(column.Name) > width {
+			width = wcwidth.StringWidth(column.Name)
+		}
+	}
+
+	for n, row := range result.Rows {
+		fmt.Fprintf(out, "*************************** %d. row ***************************\n", n+1)
+		for i, value := range row {
+			name := result.Columns[i].Name
+			fmt.Fprintf(out, "%s%s: %s\n", padding(name, width), name, valueText(value))
+		}
+	}
+}
+
+// padding returns spaces which fill the rest of the column of the given
+// width after the text. Widths are measured in terminal columns, so wide
+// and combining symbols are aligned.
+func padding(text string, width int) string {
+	if n := width - wcwidth.StringWidth(text); n > 0 {
+		return strings.Repeat(" ", n)
+	}
+	return ""
+}
+
+// formatError returns text of an error of a statement.
+func formatError(err error) string {
+	if _, ok := err.(*mysql.ServerError); ok {
+		return err.Error() + "\n"
+	}
+	return "ERROR: " + err.Error() + "\n"
+}
+
+// formatWarnings returns ", N warnings" or the empty string if there
+// are no warnings.
+func formatWarnings(warnings uint16) string {
+	switch warnings {
+	case 0:
+		return ""
+	case 1:
+		return ", 1 warning"
+	}
+	return fmt.Sprintf(", %d warnings", warnings)
+}
+
+// formatElapsed returns the execution time in seconds.
+func formatElapsed(elapsed time.Duration) string {
+	return fmt.Sprintf("%.2f sec", elapsed.Seconds())
+}
+
+// valueText returns text representation of a field.
+func valueText(value mysql.Value) string {
+	if value.Null {
+		return nullValue
+	}
+	return value.Data
+}
+
+// isNumericColumn returns true if values of the column are aligned
+// to the right.
+func isNumericColumn(column mysql.Column) bool {
+	switch column.Type {
+	case mysql.MYSQL_TYPE_DECIMAL, mysql.MYSQL_TYPE_NEWDECIMAL, mysql.MYSQL_TYPE_TINY,
+		mysql.MYSQL_TYPE_SHORT, mysql.MYSQL_TYPE_LONG, mysql.MYSQL_TYPE_FLOAT,
+		mysql.MYSQL_TYPE_DOUBLE, mysql.MYSQL_TYPE_LONGLONG, mysql.MYSQL_TYPE_INT24,
+		mysql.MYSQL_TYPE_YEAR:
+		return true
+	}
+	return false
+}
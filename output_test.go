@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xAX/mysql-tools/mysql"
+)
+
+func TestFormatTableWidth(t *testing.T) {
+	result := &mysql.Result{
+		Columns: []mysql.Column{{Name: "name"}, {Name: "n", Type: mysql.MYSQL_TYPE_LONG}},
+		Rows: [][]mysql.Value{
+			{{Data: "表格"}, {Data: "1"}},
+			{{Data: "café"}, {Data: "22"}},
+			{{Null: true}, {Data: "333"}},
+		},
+	}
+	expected := `+------+-----+
+| name | n   |
++------+-----+
+| 表格 |   1 |
+| café |  22 |
+| NULL | 333 |
++------+-----+
+`
+	out := &strings.Builder{}
+	formatTable(out, result)
+	if out.String() != expected {
+		t.Errorf("formatTable failed:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestFormatVerticalWidth(t *testing.T) {
+	result := &mysql.Result{
+		Columns: []mysql.Column{{Name: "名前"}, {Name: "n"}},
+		Rows:    [][]mysql.Value{{{Data: "a"}, {Data: "1"}}},
+	}
+	expected := `*************************** 1. row ***************************
+名前: a
+   n: 1
+`
+	out := &strings.Builder{}
+	formatVertical(out, result)
+	if out.String() != expected {
+		t.Errorf("formatVertical failed:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
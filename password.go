@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/0xAX/mysql-tools/termios"
+)
+
+const (
+	// Prompt of the password
+	PasswordPrompt = "Enter password: "
+	// passwordEnv is enviroment variable with the password
+	passwordEnv = "MYSQL_PWD"
+	// controlling terminal of the process
+	ttyPath = "/dev/tty"
+)
+
+// readPassword asks user to enter the password. The password is read
+// from the controlling terminal with disabled echo. If there is no
+// controlling terminal, the password is read from stdin.
+func readPassword() (string, error) {
+	tty, err := os.OpenFile(ttyPath, os.O_RDWR, 0)
+	if err != nil {
+		fmt.Fprint(os.Stderr, PasswordPrompt)
+		return readPasswordLine(os.Stdin)
+	}
+	defer tty.Close()
+	return readTtyPassword(tty)
+}
+
+// readTtyPassword reads the password from the terminal with disabled
+// echo. The settings of the terminal are restored after it.
+func readTtyPassword(tty *os.File) (string, error) {
+	termCtrl, err := termios.NewTermios(tty)
+	if err != nil {
+		return "", err
+	}
+	err = termios.TcGetAttr(tty, termCtrl)
+	if err != nil {
+		return "", err
+	}
+
+	// restore the terminal settings if user interrupts the prompt
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	go func() {
+		select {
+		case <-signals:
+			termios.Reset(tty, termCtrl)
+			tty.Write([]byte("\n"))
+			os.Exit(1)
+		case <-done:
+		}
+	}()
+	defer func() {
+		signal.Stop(signals)
+		close(done)
+		termios.Reset(tty, termCtrl)
+	}()
+
+	err = termios.NoEcho(tty, true, termCtrl)
+	if err != nil {
+		return "", err
+	}
+
+	tty.Write([]byte(PasswordPrompt))
+	password, err := readPasswordLine(tty)
+	// the newline typed by user is not echoed
+	tty.Write([]byte("\n"))
+
+	return password, err
+}
+
+// readPasswordLine reads a single line without the line terminator.
+func readPasswordLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
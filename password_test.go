@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/0xAX/mysql-tools/termios"
+)
+
+// openPty returns the master and the slave sides of a new pseudo
+// terminal.
+func openPty(t *testing.T) (*os.File, *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("OpenFile /dev/ptmx failed")
+	}
+	unlock, number := 0, 0
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock)))
+	if errno == 0 {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&number)))
+	}
+	if errno != 0 {
+		master.Close()
+		t.Fatal("ioctl /dev/ptmx failed", errno)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", number), os.O_RDWR, 0)
+	if err != nil {
+		master.Close()
+		t.Fatal(err)
+	}
+	return master, slave
+}
+
+func TestReadTtyPassword(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	// the password is typed after the prompt when the echo is disabled
+	go func() {
+		output := ""
+		data := make([]byte, 64)
+		for !strings.Contains(output, PasswordPrompt) {
+			n, err := master.Read(data)
+			if err != nil {
+				return
+			}
+			output += string(data[:n])
+		}
+		master.Write([]byte("secret\n"))
+	}()
+
+	password, err := readTtyPassword(slave)
+	if err != nil || password != "secret" {
+		t.Fatal("readTtyPassword failed", password, err)
+	}
+
+	data := make([]byte, 64)
+	n, _ := master.Read(data)
+	if strings.Contains(string(data[:n]), "secret") {
+		t.Error("readTtyPassword failed (echo)", string(data[:n]))
+	}
+
+	termCtrl, err := termios.NewTermios(slave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = termios.TcGetAttr(slave, termCtrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags, _ := termCtrl.GetLocalModesFlags()
+	if flags&termios.ECHO == 0 {
+		t.Error("readTtyPassword must restore the echo")
+	}
+}
+
+func TestReadPasswordLine(t *testing.T) {
+	for input, expected := range map[string]string{
+		"secret\n":   "secret",
+		"secret\r\n": "secret",
+		"secret":     "secret",
+		"\n":         "",
+	} {
+		password, err := readPasswordLine(strings.NewReader(input))
+		if err != nil || password != expected {
+			t.Errorf("readPasswordLine(%q) failed: %q", input, password)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// enables and disables the bracketed paste mode of the terminal
+	bracketedPasteOn  = "\x1b[?2004h"
+	bracketedPasteOff = "\x1b[?2004l"
+	// the terminal wraps pasted text with these sequences
+	pasteStart = "\x1b[200~"
+	pasteEnd   = "\x1b[201~"
+)
+
+// enableBracketedPaste turns on or off the bracketed paste mode. In
+// this mode pasted text is not processed as typed keys.
+func enableBracketedPaste(enable bool) {
+	if enable {
+		os.Stdout.Write([]byte(bracketedPasteOn))
+	} else {
+		os.Stdout.Write([]byte(bracketedPasteOff))
+	}
+}
+
+// readPaste reads pasted text from the input until the end of the
+// paste. New lines sent by the terminal as "\r" are converted to "\n".
+func readPaste(input io.Reader) string {
+	var c []byte = make([]byte, 1)
+	text := []byte{}
+	for !bytes.HasSuffix(text, []byte(pasteEnd)) {
+		n, err := input.Read(c)
+		if err != nil || n == 0 {
+			break
+		}
+		text = append(text, c[0])
+	}
+	pasted := strings.TrimSuffix(string(text), pasteEnd)
+	pasted = strings.Replace(pasted, "\r\n", "\n", -1)
+	return strings.Replace(pasted, "\r", "\n", -1)
+}
+
+// paste inserts pasted text to the current position.
+func paste(t *Terminal, cmd *SqlCommandBuffer) {
+	cmd.Insert([]byte(readPaste(t.Input)))
+	refreshLine(t, cmd)
+}
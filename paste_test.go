@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestReadPaste(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		rest     string
+	}{
+		{"SELECT 1;\x1b[201~", "SELECT 1;", ""},
+		// new lines are sent by terminals as CR or CR LF
+		{"SELECT 1,\r2;\x1b[201~", "SELECT 1,\n2;", ""},
+		{"SELECT 1,\r\n2;\r\n\x1b[201~", "SELECT 1,\n2;\n", ""},
+		{"a\n\rb\r\r\x1b[201~", "a\n\nb\n\n", ""},
+		// keys after the paste are not read
+		{"a\tb\x1b[201~\r", "a\tb", "\r"},
+		// escape sequences inside of pasted text are not keys
+		{"a\x1b[Ab\x1b[201~", "a\x1b[Ab", ""},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, test.input)
+		pasted := readPaste(terminal.Input)
+		rest := unreadInput(terminal)
+		restore()
+		if pasted != test.expected || string(rest) != test.rest {
+			t.Errorf("%q: pasted %q and %q is left, expected %q and %q", test.input, pasted, rest,
+				test.expected, test.rest)
+		}
+	}
+}
+
+func TestPaste(t *testing.T) {
+	terminal, restore := testTerminal(t, "1,\r2\x1b[201~")
+	defer restore()
+
+	cmd := testBuffer("SELECT ;", 7)
+	paste(terminal, cmd)
+	if cmd.String() != "SELECT 1,\n2;" || cmd.Position != 11 {
+		t.Errorf("paste failed: %q at %d", cmd.String(), cmd.Position)
+	}
+}
@@ -1,6 +1,25 @@
 package main
 
+import "strings"
+
 const (
 	// Default prompt line
 	DefaultPrompt = "mysql >"
+	// Prompt of the next line of unterminated statement
+	ContinuationPrompt = "->"
 )
+
+// Prompt returns prompt of the next line of the statement. The
+// unterminated is the opening quote of unterminated string (see
+// sql.Splitter.Unterminated). The prompt is aligned by the right
+// edge of DefaultPrompt like mysql does it.
+func Prompt(unterminated string) string {
+	prompt := ContinuationPrompt
+	if unterminated != "" {
+		prompt = unterminated + ">"
+	}
+	if len(prompt) < len(DefaultPrompt) {
+		prompt = strings.Repeat(" ", len(DefaultPrompt)-len(prompt)) + prompt
+	}
+	return prompt + " "
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/0xAX/mysql-tools/termios"
+	"github.com/0xAX/mysql-tools/wcwidth"
+)
+
+// defaultColumns is width of the terminal if it is unknown
+const defaultColumns = 80
+
+// screenPosition is a position on the screen relative to the first
+// row of the line.
+type screenPosition struct {
+	row    int
+	column int
+}
+
+// updateSize reads width of the terminal window (TIOCGWINSZ). The
+// width from terminfo is used if the size of the window is unknown.
+func (t *Terminal) updateSize() {
+	winSize, err := termios.TcGetWinSize(t.outputFd)
+	if err == nil && winSize.Cols > 0 {
+		t.columns = int(winSize.Cols)
+		return
+	}
+	if columns := t.TermInfo.Numbers["cols"]; columns > 0 {
+		t.columns = int(columns)
+		return
+	}
+	t.columns = defaultColumns
+}
+
+// watchResize redraws the line with the new width after the terminal
+// window is resized (SIGWINCH).
+func (t *Terminal) watchResize(cmd *SqlCommandBuffer) {
+	t.updateSize()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	go func() {
+		for range signals {
+			t.mutex.Lock()
+			t.updateSize()
+			// terminals reflow wrapped rows, so the row of the cursor
+			// is computed with the new width
+			cursor, _ := layout(linePrompts(t, cmd), lineText(cmd), int(cmd.Position-cmd.LineStart), t.columns)
+			t.cursorRow = cursor.row
+			refreshLine(t, cmd)
+			t.mutex.Unlock()
+		}
+	}()
+}
+
+// layout returns positions of the cursor and of the end of the text
+// which is printed after the prompts. Each line of the text is printed
+// on the new row after its prompt. The cursor is a byte offset in the
+// text. Symbols which do not fit in the rest of the row are moved to
+// the next row like terminals do it.
+func layout(prompts []string, text string, cursor int, columns int) (screenPosition, screenPosition) {
+	position := screenPosition{}
+	cursorPosition := screenPosition{}
+	// advance moves the position after the symbol and returns the
+	// position of the symbol
+	advance := func(symbol string) screenPosition {
+		width := wcwidth.GraphemeWidth(symbol)
+		if position.column+width > columns {
+			position.row++
+			position.column = 0
+		}
+		start := position
+		position.column += width
+		return start
+	}
+
+	offset := 0
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			position.row++
+			position.column = 0
+		}
+		if i < len(prompts) {
+			for j := 0; j < len(prompts[i]); {
+				n := wcwidth.NextGrapheme(prompts[i][j:])
+				advance(prompts[i][j : j+n])
+				j += n
+			}
+		}
+		for j := 0; j < len(line); {
+			n := wcwidth.NextGrapheme(line[j:])
+			start := advance(line[j : j+n])
+			if offset+j == cursor {
+				cursorPosition = start
+			}
+			j += n
+		}
+		offset += len(line) + 1
+		// the cursor at the end of the filled row of the line which
+		// is followed by other lines stays in the last column
+		if cursor == offset-1 && cursor < len(text) {
+			cursorPosition = position
+			if cursorPosition.column >= columns {
+				cursorPosition.column = columns - 1
+			}
+		}
+	}
+
+	// the position after the last column is the start of the next row
+	if position.column >= columns {
+		position.row++
+		position.column = 0
+	}
+	if cursor >= len(text) {
+		cursorPosition = position
+	}
+	return cursorPosition, position
+}
+
+// render redraws the prompts and the lines of the text starting from
+// the first row of the lines and moves the cursor to the given byte
+// offset of the text. The display is the text with terminal attributes.
+func (t *Terminal) render(prompts []string, text string, display string, cursor int) {
+	cursorPosition, end := layout(prompts, text, cursor, t.columns)
+
+	cuu1, _ := t.TermInfo.ApplyCapability("cuu1")
+	ed, _ := t.TermInfo.ApplyCapability("ed")
+	output := strings.Repeat(cuu1, t.cursorRow) + "\r" + ed
+	for i, line := range strings.Split(display, "\n") {
+		if i > 0 {
+			output += "\r\n"
+		}
+		if i < len(prompts) {
+			output += prompts[i]
+		}
+		output += line
+	}
+	// the terminal keeps the cursor in the last column after the row
+	// is filled
+	if end.column == 0 && end.row > 0 {
+		output += "\r\n"
+	}
+	output += strings.Repeat(cuu1, end.row-cursorPosition.row)
+	output += t.columnAddress(cursorPosition.column)
+	os.Stdout.Write([]byte(output))
+
+	t.cursorRow = cursorPosition.row
+}
+
+// moveCursor moves the cursor to the given byte offset of the text
+// without redrawing.
+func (t *Terminal) moveCursor(prompts []string, text string, cursor int) {
+	cursorPosition, _ := layout(prompts, text, cursor, t.columns)
+
+	output := ""
+	if cursorPosition.row < t.cursorRow {
+		cuu1, _ := t.TermInfo.ApplyCapability("cuu1")
+		output += strings.Repeat(cuu1, t.cursorRow-cursorPosition.row)
+	} else if cursorPosition.row > t.cursorRow {
+		cud1, _ := t.TermInfo.ApplyCapability("cud1")
+		output += strings.Repeat(cud1, cursorPosition.row-t.cursorRow)
+	}
+	output += t.columnAddress(cursorPosition.column)
+	os.Stdout.Write([]byte(output))
+
+	t.cursorRow = cursorPosition.row
+}
+
+// columnAddress returns sequence which moves the cursor to the column
+// of the current row.
+func (t *Terminal) columnAddress(column int) string {
+	if hpa, err := t.TermInfo.ApplyCapability("hpa", column); err == nil && hpa != "" {
+		return hpa
+	}
+	cuf1, _ := t.TermInfo.ApplyCapability("cuf1")
+	return "\r" + strings.Repeat(cuf1, column)
+}
+
+// displayReplacer replaces symbols which are shown as spaces
+var displayReplacer = strings.NewReplacer("\t", " ")
+
+// lineText returns the edited lines as they are shown. Tabs are shown
+// as spaces.
+func lineText(cmd *SqlCommandBuffer) string {
+	return displayReplacer.Replace(cmd.Line())
+}
+
+// linePrompts returns prompts of the edited lines. The first line has
+// the prompt of the statement, the next lines have the prompts which
+// are printed after Enter.
+func linePrompts(t *Terminal, cmd *SqlCommandBuffer) []string {
+	prompts := []string{t.promptText()}
+	for i := cmd.LineStart; i < cmd.Length(); i++ {
+		if cmd.Text[i] != '\n' {
+			continue
+		}
+		unterminated := ""
+		if t.splitter != nil {
+			unterminated = t.splitter.Unterminated(string(cmd.Text[:i+1]))
+		}
+		prompts = append(prompts, Prompt(unterminated))
+	}
+	return prompts
+}
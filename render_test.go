@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		prompts []string
+		text    string
+		cursor  int
+		columns int
+		at      screenPosition
+		end     screenPosition
+	}{
+		{[]string{"> "}, "abc", 1, 10, screenPosition{0, 3}, screenPosition{0, 5}},
+		{[]string{"> "}, "abc", 3, 10, screenPosition{0, 5}, screenPosition{0, 5}},
+		// the text wraps to the next rows
+		{[]string{"> "}, "abcdefghijkl", 8, 10, screenPosition{1, 0}, screenPosition{1, 4}},
+		{[]string{"> "}, "abcdefghijklmnopqrstuvwxyz", 20, 10, screenPosition{2, 2}, screenPosition{2, 8}},
+		// the row of the full width ends at the start of the next row
+		{[]string{"> "}, "abcdefgh", 8, 10, screenPosition{1, 0}, screenPosition{1, 0}},
+		{[]string{"> "}, "abcdefgh", 7, 10, screenPosition{0, 9}, screenPosition{1, 0}},
+		// wide symbols which do not fit in the row are moved to the
+		// next row
+		{[]string{"> "}, "abcdefg表", 7, 10, screenPosition{1, 0}, screenPosition{1, 2}},
+		{[]string{"> "}, "abcdefg表x", 10, 10, screenPosition{1, 2}, screenPosition{1, 3}},
+		{[]string{"> "}, "abcdef表", 6, 10, screenPosition{0, 8}, screenPosition{1, 0}},
+		// combining symbols do not use columns
+		{[]string{"> "}, "e\u0301x", 3, 10, screenPosition{0, 3}, screenPosition{0, 4}},
+		// lines are printed on the new rows after their prompts
+		{[]string{"> ", "-> "}, "ab\ncd", 4, 10, screenPosition{1, 4}, screenPosition{1, 5}},
+		{[]string{"> ", "-> "}, "ab\ncd", 2, 10, screenPosition{0, 4}, screenPosition{1, 5}},
+		{[]string{"> ", "-> "}, "ab\ncd", 3, 10, screenPosition{1, 3}, screenPosition{1, 5}},
+		{[]string{"> ", "-> "}, "ab\n", 3, 10, screenPosition{1, 3}, screenPosition{1, 3}},
+		{[]string{"> ", "-> "}, "abcdefghijk\ncd", 13, 10, screenPosition{2, 4}, screenPosition{2, 5}},
+		// the cursor at the end of the filled row stays in the last
+		// column if other lines follow
+		{[]string{"> ", "-> "}, "abcdefgh\nx", 8, 10, screenPosition{0, 9}, screenPosition{1, 4}},
+	}
+	for _, test := range tests {
+		at, end := layout(test.prompts, test.text, test.cursor, test.columns)
+		if at != test.at || end != test.end {
+			t.Errorf("layout(%q, %q, %d, %d) = %v, %v, expected %v, %v", test.prompts, test.text, test.cursor,
+				test.columns, at, end, test.at, test.end)
+		}
+	}
+}
@@ -6,4 +6,18 @@ echo "Run ./terminfo tests"
 go test ./terminfo/
 echo "Run ./termios tests"
 go test ./termios/
+echo "Run ./mysql tests"
+go test ./mysql/
+echo "Run ./options tests"
+go test ./options/
+echo "Run ./history tests"
+go test ./history/
+echo "Run ./inputrc tests"
+go test ./inputrc/
+echo "Run ./sql tests"
+go test ./sql/
+echo "Run ./wcwidth tests"
+go test ./wcwidth/
+echo "Run mysql-cli tests"
+go test .
 echo "Done."
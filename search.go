@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/0xAX/mysql-tools/wcwidth"
+)
+
+// historySearch is state of the incremental search through the
+// history (Ctrl-R/Ctrl-S).
+type historySearch struct {
+	query    string // text to search for
+	index    int    // index of the matched entry
+	found    bool   // false if there is no match for the query
+	backward bool   // direction of the search
+}
+
+// label returns the prompt of the search like bash shows it.
+func (s *historySearch) label() string {
+	label := "i-search"
+	if s.backward {
+		label = "reverse-i-search"
+	}
+	if !s.found && s.query != "" {
+		label = "failed " + label
+	}
+	return "(" + label + ")`" + s.query + "': "
+}
+
+// next looks for the query starting with the given index.
+func (s *historySearch) next(t *Terminal, start int) {
+	index, ok := t.History.Search(s.query, start, s.backward)
+	if ok {
+		s.index = index
+	}
+	s.found = ok
+}
+
+// extend adds the text to the query and looks for it starting with the
+// current match or with the newest entry.
+func (s *historySearch) extend(t *Terminal, text string) {
+	s.query += text
+	start := s.index
+	if start == len(t.History.Entries()) {
+		start--
+	}
+	s.next(t, start)
+}
+
+// reverseSearch runs incremental search through the history in the
+// given direction. Typed symbols are added to the query, Ctrl-R and
+// Ctrl-S look for older and newer matches. Enter accepts the match and
+// returns true, so the statement is executed, Esc or Ctrl-G restore
+// the line. Other keys accept the match for editing, pasted text is
+// added to the query.
+func reverseSearch(t *Terminal, cmd *SqlCommandBuffer, backward bool) bool {
+	line := cmd.Line()
+	position := cmd.Position
+	entries := t.History.Entries()
+	search := &historySearch{index: len(entries), backward: backward}
+
+	drawSearch(t, search)
+	for {
+		key, err := t.Input.ReadKey()
+		if err != nil {
+			acceptSearch(t, cmd, search)
+			return false
+		}
+
+		c := key.Sequence[0]
+		switch c {
+		case CTRL_R, CTRL_S:
+			backward := c == CTRL_R
+			start := search.index
+			if search.found || backward != search.backward {
+				if backward {
+					start--
+				} else {
+					start++
+				}
+			}
+			search.backward = backward
+			search.next(t, start)
+		case BACKSPACE, CTRL_H:
+			if search.query != "" {
+				search.query = search.query[:len(search.query)-wcwidth.PreviousGrapheme(search.query)]
+				search.next(t, search.index)
+			}
+		case CTRL_G:
+			cmd.SetLine(line)
+			cmd.Position = position
+			refreshLine(t, cmd)
+			return false
+		case ESC:
+			if key.Name == "Escape" {
+				cmd.SetLine(line)
+				cmd.Position = position
+				refreshLine(t, cmd)
+				return false
+			}
+			if key.Sequence == pasteStart {
+				// pasted text is added to the query
+				search.extend(t, strings.Replace(readPaste(t.Input), "\n", " ", -1))
+				break
+			}
+			// accept the match on arrows and other keys
+			acceptSearch(t, cmd, search)
+			return false
+		case ENTER:
+			acceptSearch(t, cmd, search)
+			return true
+		default:
+			if c < ' ' {
+				acceptSearch(t, cmd, search)
+				return false
+			}
+			search.extend(t, key.Sequence)
+		}
+		drawSearch(t, search)
+	}
+}
+
+// acceptSearch replaces the current line with the matched entry and
+// moves the cursor to the match.
+func acceptSearch(t *Terminal, cmd *SqlCommandBuffer, search *historySearch) {
+	entries := t.History.Entries()
+	t.History.Reset()
+	if search.index < len(entries) && search.query != "" {
+		entry := entries[search.index]
+		cmd.SetLine(entry)
+		if match := strings.Index(entry, search.query); match >= 0 {
+			cmd.Position = cmd.LineStart + uint64(match)
+		}
+	}
+	refreshLine(t, cmd)
+}
+
+// drawSearch draws the search prompt and the matched entry. The match
+// is highlighted with the standout mode of the terminal.
+func drawSearch(t *Terminal, search *historySearch) {
+	entry := ""
+	entries := t.History.Entries()
+	if search.index < len(entries) && search.query != "" {
+		entry = strings.Replace(entries[search.index], "\n", " ", -1)
+	}
+
+	display := entry
+	cursor := len(entry)
+	if match := strings.Index(entry, search.query); entry != "" && match >= 0 {
+		smso, _ := t.TermInfo.ApplyCapability("smso")
+		rmso, _ := t.TermInfo.ApplyCapability("rmso")
+		display = entry[:match] + smso + search.query + rmso + entry[match+len(search.query):]
+		// the cursor is placed at the beginning of the match
+		cursor = match
+	}
+	t.render([]string{search.label()}, entry, display, cursor)
+}
@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestReverseSearch(t *testing.T) {
+	tests := []struct {
+		keys     string
+		backward bool
+		execute  bool
+		line     string
+		position uint64
+	}{
+		// Enter accepts the match for execution
+		{"SEL\r", true, true, "SELECT 3;", 0},
+		{"FROM\r", true, true, "SELECT 2 FROM t;", 9},
+		// Ctrl-R and Ctrl-S look for older and newer matches
+		{"SEL\x12\r", true, true, "SELECT 2 FROM t;", 0},
+		{"SEL\x12\x12\r", true, true, "SELECT 1;", 0},
+		{"SEL\x12\x12\x12\r", true, true, "SELECT 1;", 0},
+		{"SEL\x12\x12\x13\r", true, true, "SELECT 2 FROM t;", 0},
+		{"SEL\r", false, true, "SELECT 3;", 0},
+		// Backspace removes the last symbol of the query
+		{"SHX\x7f\r", true, true, "SHOW TABLES;", 0},
+		// pasted text is added to the query
+		{"\x1b[200~SHOW\r\nT\x1b[201~\r", true, true, "SHOW TABLES;", 0},
+		// other keys accept the match for editing
+		{"FROM\x02", true, false, "SELECT 2 FROM t;", 9},
+		// Ctrl-G aborts the search
+		{"SEL\x07", true, false, "abc", 1},
+		// and a lone Escape too
+		{"SEL\x1b", true, false, "abc", 1},
+		// the line is kept if there is no match
+		{"XYZ\r", true, true, "abc", 1},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, test.keys)
+		for _, entry := range []string{"SELECT 1;", "SELECT 2 FROM t;", "SHOW TABLES;", "SELECT 3;"} {
+			terminal.History.Add(entry)
+		}
+		cmd := testBuffer("abc", 1)
+		execute := reverseSearch(terminal, cmd, test.backward)
+		restore()
+		if execute != test.execute || cmd.Line() != test.line || cmd.Position != test.position {
+			t.Errorf("%q: %v %q at %d, expected %v %q at %d", test.keys, execute, cmd.Line(), cmd.Position,
+				test.execute, test.line, test.position)
+		}
+	}
+}
+
+func TestSearchLabel(t *testing.T) {
+	tests := []struct {
+		search   historySearch
+		expected string
+	}{
+		{historySearch{query: "", backward: true}, "(reverse-i-search)`': "},
+		{historySearch{query: "sel", found: true, backward: true}, "(reverse-i-search)`sel': "},
+		{historySearch{query: "sel", found: true}, "(i-search)`sel': "},
+		{historySearch{query: "x", backward: true}, "(failed reverse-i-search)`x': "},
+	}
+	for _, test := range tests {
+		if label := test.search.label(); label != test.expected {
+			t.Errorf("label failed: %q, expected %q", label, test.expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package sql
+
+import "strings"
+
+// keywords are reserved words and frequently used keywords of MySQL
+// (see https://dev.mysql.com/doc/refman/5.7/en/keywords.html).
+var keywords = map[string]bool{}
+
+func init() {
+	for _, keyword := range strings.Fields(`
+		ACCESSIBLE ADD AFTER AGAINST ALGORITHM ALL ALTER ANALYZE AND AS ASC
+		ASENSITIVE AUTO_INCREMENT BEFORE BEGIN BETWEEN BIGINT BINARY BLOB
+		BOTH BY CALL CASCADE CASE CHANGE CHAR CHARACTER CHARSET CHECK COLLATE
+		COLUMN COLUMNS COMMENT COMMIT CONDITION CONSTRAINT CONTINUE CONVERT
+		CREATE CROSS CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER
+		CURSOR DATABASE DATABASES DATE DATETIME DAY_HOUR DAY_MICROSECOND
+		DAY_MINUTE DAY_SECOND DEC DECIMAL DECLARE DEFAULT DELAYED DELETE
+		DESC DESCRIBE DETERMINISTIC DISTINCT DISTINCTROW DIV DO DOUBLE DROP
+		DUAL DUPLICATE EACH ELSE ELSEIF ENCLOSED END ENGINE ENUM ESCAPED
+		EXISTS EXIT EXPLAIN FALSE FETCH FIELDS FLOAT FLOAT4 FLOAT8 FLUSH FOR
+		FORCE FOREIGN FROM FULL FULLTEXT FUNCTION GENERATED GET GLOBAL GRANT
+		GRANTS GROUP HANDLER HAVING HIGH_PRIORITY HOUR_MICROSECOND
+		HOUR_MINUTE HOUR_SECOND IF IGNORE IN INDEX INFILE INNER INOUT
+		INSENSITIVE INSERT INT INT1 INT2 INT3 INT4 INT8 INTEGER INTERVAL INTO
+		IO_AFTER_GTIDS IO_BEFORE_GTIDS IS ITERATE JOIN JSON KEY KEYS KILL
+		LEADING LEAVE LEFT LIKE LIMIT LINEAR LINES LOAD LOCAL LOCALTIME
+		LOCALTIMESTAMP LOCK LONG LONGBLOB LONGTEXT LOOP LOW_PRIORITY
+		MASTER_BIND MASTER_SSL_VERIFY_SERVER_CERT MATCH MAXVALUE MEDIUMBLOB
+		MEDIUMINT MEDIUMTEXT MIDDLEINT MINUTE_MICROSECOND MINUTE_SECOND MOD
+		MODIFIES MODIFY NATURAL NOT NO_WRITE_TO_BINLOG NULL NUMERIC OFFSET ON
+		OPTIMIZE OPTIMIZER_COSTS OPTION OPTIONALLY OR ORDER OUT OUTER OUTFILE
+		PARTITION PRECISION PRIMARY PRIVILEGES PROCEDURE PROCESSLIST PURGE
+		RANGE READ READS READ_WRITE REAL REFERENCES REGEXP RELEASE RENAME
+		REPEAT REPLACE REQUIRE RESIGNAL RESTRICT RETURN RETURNS REVOKE RIGHT
+		RLIKE ROLLBACK ROW ROWS SCHEMA SCHEMAS SECOND_MICROSECOND SELECT
+		SENSITIVE SEPARATOR SESSION SET SHOW SIGNAL SMALLINT SPATIAL SPECIFIC
+		SQL SQLEXCEPTION SQLSTATE SQLWARNING SQL_BIG_RESULT
+		SQL_CALC_FOUND_ROWS SQL_SMALL_RESULT SSL START STARTING STATUS
+		STORED STRAIGHT_JOIN TABLE TABLES TEMPORARY TERMINATED TEXT THEN TIME
+		TIMESTAMP TINYBLOB TINYINT TINYTEXT TO TRAILING TRANSACTION TRIGGER
+		TRUE TRUNCATE UNDO UNION UNIQUE UNLOCK UNSIGNED UPDATE USAGE USE
+		USING UTC_DATE UTC_TIME UTC_TIMESTAMP VALUES VARBINARY VARCHAR
+		VARCHARACTER VARIABLES VARYING VIEW VIRTUAL WARNINGS WHEN WHERE WHILE
+		WITH WRITE XOR YEAR_MONTH ZEROFILL
+	`) {
+		keywords[keyword] = true
+	}
+}
+
+// IsKeyword returns true if the word is a keyword of MySQL. The case
+// of the word is ignored.
+func IsKeyword(word string) bool {
+	return keywords[strings.ToUpper(word)]
+}
@@ -0,0 +1,240 @@
+// sql package provides lexical analysis of SQL statements typed in
+// the mysql-cli: splitting of the input into statements by delimiters
+// and tokens for highlighting.
+package sql
+
+import "strings"
+
+// TokenType is a type of a lexical token.
+type TokenType int
+
+// Types of tokens
+const (
+	TOKEN_WHITESPACE        TokenType = iota // spaces, tabs and new lines
+	TOKEN_COMMENT                            // -- comment, # comment or /* comment */
+	TOKEN_STRING                             // 'string' or "string"
+	TOKEN_QUOTED_IDENTIFIER                  // `identifier`
+	TOKEN_WORD                               // keyword or unquoted identifier
+	TOKEN_NUMBER                             // 1, 1.5, 1e10, 0xff
+	TOKEN_VARIABLE                           // @variable or @@system_variable
+	TOKEN_OPERATOR                           // operators and punctuation
+	TOKEN_DELIMITER                          // the current statement delimiter
+	TOKEN_COMMAND                            // \g, \G and other backslash commands
+	TOKEN_DELIMITER_COMMAND                  // DELIMITER command which changes the delimiter
+)
+
+// DefaultDelimiter is the default statement delimiter.
+const DefaultDelimiter = ";"
+
+// Token is a lexical token of the input.
+type Token struct {
+	Type         TokenType // type of the token
+	Text         string    // text of the token
+	Start        int       // byte offset of the token in the input
+	Unterminated bool      // true for a string, identifier or comment without the closing quote
+}
+
+// Tokenize splits the text into tokens. The delimiter is the statement
+// delimiter at the beginning of the text, DELIMITER commands change it.
+// The second returned value is the delimiter at the end of the text.
+func Tokenize(text string, delimiter string) ([]Token, string) {
+	tokens := []Token{}
+	statementStart := true
+
+	for i := 0; i < len(text); {
+		token := Token{Start: i}
+		c := text[i]
+
+		switch {
+		case statementStart && isDelimiterCommand(text[i:]):
+			// DELIMITER command takes the rest of the line
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				token.Type = TOKEN_DELIMITER_COMMAND
+				token.Unterminated = true
+				end = len(text) - i
+			} else {
+				token.Type = TOKEN_DELIMITER_COMMAND
+				fields := strings.Fields(text[i : i+end])
+				if len(fields) > 1 {
+					delimiter = fields[1]
+				}
+			}
+			token.Text = text[i : i+end]
+		case strings.HasPrefix(text[i:], delimiter):
+			token.Type = TOKEN_DELIMITER
+			token.Text = delimiter
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			token.Type = TOKEN_WHITESPACE
+			token.Text = scanWhile(text[i:], isSpace)
+		case c == '#' || isDashComment(text[i:]):
+			token.Type = TOKEN_COMMENT
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			token.Text = text[i : i+end]
+		case strings.HasPrefix(text[i:], "/*"):
+			token.Type = TOKEN_COMMENT
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				token.Text = text[i:]
+				token.Unterminated = true
+			} else {
+				token.Text = text[i : i+2+end+2]
+			}
+		case c == '\'' || c == '"':
+			token.Type = TOKEN_STRING
+			token.Text, token.Unterminated = scanQuoted(text[i:], c, true)
+		case c == '`':
+			token.Type = TOKEN_QUOTED_IDENTIFIER
+			token.Text, token.Unterminated = scanQuoted(text[i:], c, false)
+		case c == '\\' && i+1 < len(text):
+			token.Type = TOKEN_COMMAND
+			token.Text = text[i : i+2]
+		case c == '@':
+			token.Type = TOKEN_VARIABLE
+			token.Text = "@" + scanVariable(text[i+1:])
+		case isDigit(c) || (c == '.' && i+1 < len(text) && isDigit(text[i+1])):
+			token.Type = TOKEN_NUMBER
+			token.Text = scanNumber(text[i:])
+		case isWordChar(c):
+			token.Type = TOKEN_WORD
+			token.Text = scanWhile(text[i:], isWordChar)
+		default:
+			token.Type = TOKEN_OPERATOR
+			token.Text = text[i : i+1]
+		}
+
+		switch token.Type {
+		case TOKEN_DELIMITER, TOKEN_DELIMITER_COMMAND:
+			statementStart = true
+		case TOKEN_COMMAND:
+			statementStart = IsTerminator(token)
+		case TOKEN_WHITESPACE, TOKEN_COMMENT:
+		default:
+			statementStart = false
+		}
+
+		tokens = append(tokens, token)
+		i += len(token.Text)
+	}
+
+	return tokens, delimiter
+}
+
+// IsTerminator returns true if the token terminates a statement: the
+// delimiter, \g, \G or \e which opens the statement in the editor.
+func IsTerminator(token Token) bool {
+	if token.Type == TOKEN_DELIMITER {
+		return true
+	}
+	switch token.Text {
+	case "\\g", "\\G", "\\e":
+		return token.Type == TOKEN_COMMAND
+	}
+	return false
+}
+
+// isDelimiterCommand returns true if the text starts with the
+// DELIMITER command.
+func isDelimiterCommand(text string) bool {
+	const command = "delimiter"
+	if len(text) <= len(command) || !strings.EqualFold(text[:len(command)], command) {
+		return false
+	}
+	return text[len(command)] == ' ' || text[len(command)] == '\t'
+}
+
+// isDashComment returns true if the text starts with "-- " comment. The
+// dashes must be followed by a whitespace or the end of the line.
+func isDashComment(text string) bool {
+	if !strings.HasPrefix(text, "--") {
+		return false
+	}
+	return len(text) == 2 || isSpace(text[2])
+}
+
+// scanQuoted returns a quoted string or identifier. The quote can be
+// escaped by doubling, backslash escapes are allowed in strings only.
+// The second returned value is true if the closing quote is missing.
+func scanQuoted(text string, quote byte, backslash bool) (string, bool) {
+	for i := 1; i < len(text); i++ {
+		switch {
+		case backslash && text[i] == '\\':
+			i++
+		case text[i] == quote:
+			if i+1 < len(text) && text[i+1] == quote {
+				i++
+				continue
+			}
+			return text[:i+1], false
+		}
+	}
+	return text, true
+}
+
+// scanVariable returns a name of a user or system variable without
+// the leading "@".
+func scanVariable(text string) string {
+	prefix := ""
+	if strings.HasPrefix(text, "@") {
+		prefix = "@"
+		text = text[1:]
+	}
+	if len(text) > 0 && (text[0] == '\'' || text[0] == '"' || text[0] == '`') {
+		quoted, _ := scanQuoted(text, text[0], text[0] != '`')
+		return prefix + quoted
+	}
+	return prefix + scanWhile(text, func(c byte) bool {
+		return isWordChar(c) || c == '.'
+	})
+}
+
+// scanNumber returns a numeric literal.
+func scanNumber(text string) string {
+	if len(text) > 2 && text[0] == '0' && (text[1] == 'x' || text[1] == 'X' || text[1] == 'b' || text[1] == 'B') {
+		return text[:2] + scanWhile(text[2:], isWordChar)
+	}
+
+	i := len(scanWhile(text, isDigit))
+	if i < len(text) && text[i] == '.' {
+		i += 1 + len(scanWhile(text[i+1:], isDigit))
+	}
+	if i+1 < len(text) && (text[i] == 'e' || text[i] == 'E') {
+		j := i + 1
+		if text[j] == '+' || text[j] == '-' {
+			j++
+		}
+		if digits := scanWhile(text[j:], isDigit); digits != "" {
+			i = j + len(digits)
+		}
+	}
+	// identifiers may start with digits, e.g. 1table
+	if i < len(text) && isWordChar(text[i]) && !isDigit(text[i]) {
+		return text[:i] + scanWhile(text[i:], isWordChar)
+	}
+	return text[:i]
+}
+
+func scanWhile(text string, f func(byte) bool) string {
+	i := 0
+	for i < len(text) && f(text[i]) {
+		i++
+	}
+	return text[:i]
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isWordChar returns true for characters of unquoted identifiers.
+// Bytes of multibyte UTF-8 characters are allowed in identifiers.
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
@@ -0,0 +1,88 @@
+package sql
+
+// brackets are pairs of the opening and closing brackets
+var brackets = map[string]string{"(": ")"}
+
+// MatchingBracket returns byte offset of the bracket or quote which
+// matches the one at the given offset of the text, or -1 if there is
+// no such symbol. Brackets inside strings, quoted identifiers and
+// comments are ignored. Quotes are matched within the same string or
+// quoted identifier only.
+func MatchingBracket(text string, offset int, delimiter string) int {
+	tokens, _ := Tokenize(text, delimiter)
+	for i, token := range tokens {
+		if offset < token.Start || offset >= token.Start+len(token.Text) {
+			continue
+		}
+
+		switch token.Type {
+		case TOKEN_STRING, TOKEN_QUOTED_IDENTIFIER:
+			if token.Unterminated {
+				return -1
+			}
+			end := token.Start + len(token.Text) - 1
+			switch offset {
+			case token.Start:
+				return end
+			case end:
+				return token.Start
+			}
+		case TOKEN_OPERATOR:
+			if _, ok := brackets[token.Text]; ok {
+				return matchForward(tokens[i:])
+			}
+			for open, close := range brackets {
+				if token.Text == close {
+					return matchBackward(tokens[:i+1], open, close)
+				}
+			}
+		}
+		return -1
+	}
+	return -1
+}
+
+// matchForward returns offset of the closing bracket of the first
+// token or -1 if the bracket is not closed.
+func matchForward(tokens []Token) int {
+	open := tokens[0].Text
+	close := brackets[open]
+	depth := 0
+	for _, token := range tokens {
+		if token.Type != TOKEN_OPERATOR {
+			continue
+		}
+		switch token.Text {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return token.Start
+			}
+		}
+	}
+	return -1
+}
+
+// matchBackward returns offset of the opening bracket of the last token
+// or -1 if there is no such bracket.
+func matchBackward(tokens []Token, open string, close string) int {
+	depth := 0
+	for i := len(tokens) - 1; i >= 0; i-- {
+		token := tokens[i]
+		if token.Type != TOKEN_OPERATOR {
+			continue
+		}
+		switch token.Text {
+		case close:
+			depth++
+		case open:
+			depth--
+			if depth == 0 {
+				return token.Start
+			}
+		}
+	}
+	return -1
+}
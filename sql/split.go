@@ -0,0 +1,80 @@
+// sql package provides lexical analysis of SQL statements typed in
+// the mysql-cli.
+package sql
+
+import "strings"
+
+// Statement is a complete statement of the input.
+type Statement struct {
+	Text       string // text of the statement without the terminator
+	Terminator string // the delimiter, "\g", "\G" or "\e"
+}
+
+// Splitter splits the input into statements. It keeps the current
+// delimiter between calls.
+type Splitter struct {
+	// Delimiter is the current statement delimiter
+	Delimiter string
+}
+
+// NewSplitter returns splitter with the default delimiter.
+func NewSplitter() *Splitter {
+	return &Splitter{Delimiter: DefaultDelimiter}
+}
+
+// Split returns complete statements of the text and the rest of the text
+// which is not terminated yet. Delimiters inside strings, quoted
+// identifiers and comments are ignored. DELIMITER commands which are
+// terminated by the new line change the delimiter of the splitter.
+func (s *Splitter) Split(text string) ([]Statement, string) {
+	statements := []Statement{}
+	delimiter := s.Delimiter
+	start := 0
+
+	for _, token := range s.tokenize(text) {
+		switch {
+		case token.Type == TOKEN_DELIMITER_COMMAND && !token.Unterminated:
+			fields := strings.Fields(token.Text)
+			if len(fields) > 1 {
+				delimiter = fields[1]
+			}
+			start = token.Start + len(token.Text)
+		case IsTerminator(token):
+			statement := strings.TrimSpace(text[start:token.Start])
+			statements = append(statements, Statement{Text: statement, Terminator: token.Text})
+			start = token.Start + len(token.Text)
+		}
+	}
+	s.Delimiter = delimiter
+
+	return statements, text[start:]
+}
+
+// tokenize splits the text to tokens starting with the current delimiter.
+func (s *Splitter) tokenize(text string) []Token {
+	tokens, _ := Tokenize(text, s.Delimiter)
+	return tokens
+}
+
+// Unterminated returns the opening quote of a string or identifier
+// ("'", "\"" or "`") or "/*" of a comment which is not closed at the
+// end of the text. It returns the empty string if there is no such
+// token.
+func (s *Splitter) Unterminated(text string) string {
+	tokens := s.tokenize(text)
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	last := tokens[len(tokens)-1]
+	if !last.Unterminated {
+		return ""
+	}
+	switch last.Type {
+	case TOKEN_STRING, TOKEN_QUOTED_IDENTIFIER:
+		return last.Text[:1]
+	case TOKEN_COMMENT:
+		return "/*"
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+// sql package provides lexical analysis of SQL statements typed in
+// the mysql-cli.
+package sql
+
+import "testing"
+
+func TestTokenize(t *testing.T) {
+	tokens, delimiter := Tokenize("SELECT `a``b`, 'it''s', @@sql_mode, 1.5e3 -- comment\n/* c */;", DefaultDelimiter)
+	expected := []Token{
+		{TOKEN_WORD, "SELECT", 0, false},
+		{TOKEN_WHITESPACE, " ", 6, false},
+		{TOKEN_QUOTED_IDENTIFIER, "`a``b`", 7, false},
+		{TOKEN_OPERATOR, ",", 13, false},
+		{TOKEN_WHITESPACE, " ", 14, false},
+		{TOKEN_STRING, "'it''s'", 15, false},
+		{TOKEN_OPERATOR, ",", 22, false},
+		{TOKEN_WHITESPACE, " ", 23, false},
+		{TOKEN_VARIABLE, "@@sql_mode", 24, false},
+		{TOKEN_OPERATOR, ",", 34, false},
+		{TOKEN_WHITESPACE, " ", 35, false},
+		{TOKEN_NUMBER, "1.5e3", 36, false},
+		{TOKEN_WHITESPACE, " ", 41, false},
+		{TOKEN_COMMENT, "-- comment", 42, false},
+		{TOKEN_WHITESPACE, "\n", 52, false},
+		{TOKEN_COMMENT, "/* c */", 53, false},
+		{TOKEN_DELIMITER, ";", 60, false},
+	}
+	if delimiter != DefaultDelimiter {
+		t.Error("Tokenize failed (delimiter)")
+	}
+	if len(tokens) != len(expected) {
+		t.Fatal("Tokenize failed (number of tokens)", tokens)
+	}
+	for i := range expected {
+		if tokens[i] != expected[i] {
+			t.Error("Tokenize failed", tokens[i], expected[i])
+		}
+	}
+
+	tokens, _ = Tokenize("SELECT 'abc\\'", DefaultDelimiter)
+	if !tokens[len(tokens)-1].Unterminated {
+		t.Error("Tokenize failed (escaped quote)")
+	}
+
+	tokens, _ = Tokenize("a--b", DefaultDelimiter)
+	if len(tokens) != 4 || tokens[1].Type != TOKEN_OPERATOR {
+		t.Error("Tokenize failed (-- without space is not a comment)", tokens)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	splitter := NewSplitter()
+	statements, rest := splitter.Split("SELECT ';' AS `;`; # ;\nSELECT 2\\G select /* ; */ 3")
+	if len(statements) != 2 {
+		t.Fatal("Split failed (number of statements)", statements)
+	}
+	if statements[0].Text != "SELECT ';' AS `;`" || statements[0].Terminator != ";" {
+		t.Error("Split failed (first statement)", statements[0])
+	}
+	if statements[1].Text != "# ;\nSELECT 2" || statements[1].Terminator != "\\G" {
+		t.Error("Split failed (second statement)", statements[1])
+	}
+	if rest != " select /* ; */ 3" {
+		t.Error("Split failed (rest)", rest)
+	}
+
+	statements, rest = splitter.Split("SELECT 1;\n")
+	if len(statements) != 1 || rest != "\n" {
+		t.Error("Split failed (trailing new line)")
+	}
+
+	statements, rest = splitter.Split("SELECT 1 \\e SELECT 2")
+	if len(statements) != 1 || statements[0].Text != "SELECT 1" || statements[0].Terminator != "\\e" || rest != " SELECT 2" {
+		t.Error("Split failed (\\e)", statements, rest)
+	}
+}
+
+func TestSplitDelimiter(t *testing.T) {
+	splitter := NewSplitter()
+	statements, rest := splitter.Split("DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; END//\ndelimiter ;\nSELECT 2;")
+	if len(statements) != 2 || rest != "" {
+		t.Fatal("Split failed (DELIMITER)", statements, rest)
+	}
+	if statements[0].Text != "CREATE PROCEDURE p() BEGIN SELECT 1; END" || statements[0].Terminator != "//" {
+		t.Error("Split failed (procedure)", statements[0])
+	}
+	if splitter.Delimiter != ";" {
+		t.Error("Split failed (restored delimiter)")
+	}
+
+	// the DELIMITER command is applied after the new line only
+	statements, rest = splitter.Split("delimiter $$")
+	if len(statements) != 0 || rest != "delimiter $$" || splitter.Delimiter != ";" {
+		t.Error("Split failed (unterminated DELIMITER)")
+	}
+	splitter.Split("delimiter $$\n")
+	if splitter.Delimiter != "$$" {
+		t.Error("Split failed (DELIMITER $$)")
+	}
+
+	// DELIMITER is a command only at the beginning of a statement
+	splitter = NewSplitter()
+	statements, _ = splitter.Split("SELECT delimiter FROM t;\n")
+	if len(statements) != 1 || splitter.Delimiter != ";" {
+		t.Error("Split failed (delimiter column)")
+	}
+}
+
+func TestUnterminated(t *testing.T) {
+	splitter := NewSplitter()
+	for text, expected := range map[string]string{
+		"SELECT 1":             "",
+		"SELECT 'abc":          "'",
+		"SELECT \"abc":         "\"",
+		"SELECT `abc":          "`",
+		"SELECT /* comment":    "/*",
+		"SELECT 'a' /* c */ 1": "",
+	} {
+		if splitter.Unterminated(text) != expected {
+			t.Error("Unterminated failed", text)
+		}
+	}
+}
+
+func TestIsKeyword(t *testing.T) {
+	for _, word := range []string{"SELECT", "select", "Where", "DELIMITER_", "users", ""} {
+		expected := word == "SELECT" || word == "select" || word == "Where"
+		if IsKeyword(word) != expected {
+			t.Error("IsKeyword failed", word)
+		}
+	}
+}
+
+func TestMatchingBracket(t *testing.T) {
+	text := "SELECT f(a, (b), ')') FROM `t` WHERE c = 'it''s' -- ("
+	tests := []struct {
+		offset   int
+		expected int
+	}{
+		{8, 20},  // ( of f(
+		{20, 8},  // ) of f()
+		{12, 14}, // (b)
+		{14, 12},
+		{18, -1}, // ) inside the string
+		{17, 19}, // quotes of ')'
+		{19, 17},
+		{27, 29}, // `t`
+		{41, 47}, // 'it''s'
+		{44, -1}, // doubled quote
+		{0, -1},  // not a bracket
+		{52, -1}, // ( inside the comment
+	}
+	for _, test := range tests {
+		result := MatchingBracket(text, test.offset, DefaultDelimiter)
+		if result != test.expected {
+			t.Errorf("MatchingBracket(%d) failed: %d, expected %d", test.offset, result, test.expected)
+		}
+	}
+
+	if MatchingBracket("SELECT (1", 7, DefaultDelimiter) != -1 || MatchingBracket("SELECT 'a", 7, DefaultDelimiter) != -1 {
+		t.Error("MatchingBracket failed (unterminated)")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import "strings"
+
+// autosuggestion returns the rest of the newest history entry which
+// starts with the buffer. Entries of the current database are
+// suggested first. Suggestions are shown for non-empty lines only.
+func autosuggestion(t *Terminal, cmd *SqlCommandBuffer) string {
+	if !t.Suggestions || strings.TrimSpace(cmd.Line()) == "" {
+		return ""
+	}
+	// the suggestion continues the text after the cursor, so it is not
+	// shown if the cursor is not at the end of the buffer
+	if cmd.Position != cmd.Length() {
+		return ""
+	}
+	if style, _ := suggestionStyle(t); style == "" {
+		return ""
+	}
+
+	database := ""
+	if t.Conn != nil {
+		database = t.Conn.Database()
+	}
+	entry, ok := t.History.Suggest(cmd.String(), database)
+	if !ok {
+		return ""
+	}
+	return entry[cmd.Length():]
+}
+
+// suggestionStyle returns sequences which start and stop the dim mode
+// of the suggestion.
+func suggestionStyle(t *Terminal) (string, string) {
+	dim, _ := t.TermInfo.ApplyCapability("dim")
+	sgr0, _ := t.TermInfo.ApplyCapability("sgr0")
+	if dim == "" || sgr0 == "" {
+		return "", ""
+	}
+	return dim, sgr0
+}
+
+// acceptSuggestion inserts the shown suggestion or its first word only
+// (Right, End and Alt-F). It returns false if there is no suggestion.
+func acceptSuggestion(t *Terminal, cmd *SqlCommandBuffer, word bool) bool {
+	if t.suggestion == "" || cmd.Position != cmd.Length() {
+		return false
+	}
+	text := t.suggestion
+	if word {
+		end := 0
+		for end < len(text) && !isWordSymbol(text[end]) {
+			end++
+		}
+		for end < len(text) && isWordSymbol(text[end]) {
+			end++
+		}
+		text = text[:end]
+	}
+	cmd.Insert([]byte(text))
+	refreshLine(t, cmd)
+	return true
+}
@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+// suggestTerminal returns the terminal with suggestions of the history
+// entries.
+func suggestTerminal(t *testing.T, entries ...string) (*Terminal, func()) {
+	terminal, restore := testTerminal(t, "")
+	terminal.Suggestions = true
+	terminal.TermInfo.Strings["dim"] = "\x1b[2m"
+	terminal.TermInfo.Strings["sgr0"] = "\x1b[m"
+	for _, entry := range entries {
+		terminal.History.Add(entry)
+	}
+	return terminal, restore
+}
+
+func TestAutosuggestion(t *testing.T) {
+	tests := []struct {
+		text       string
+		position   uint64
+		suggestion string
+	}{
+		{"SELECT *", 8, " FROM users;"},
+		{"SELECT * FROM users;", 20, ""},
+		{"SELECT 1", 8, ""},
+		{"  ", 2, ""},
+		{"", 0, ""},
+		// suggestions are shown at the end of the buffer only
+		{"SELECT *", 7, ""},
+		{"SELECT *", 0, ""},
+		{"SELECT *\nFROM", 8, ""},
+		{"SELECT *\nFROM", 13, " users;"},
+	}
+	for _, test := range tests {
+		terminal, restore := suggestTerminal(t, "SELECT *\nFROM users;", "SELECT * FROM users;", "SHOW TABLES;")
+		suggestion := autosuggestion(terminal, testBuffer(test.text, test.position))
+		restore()
+		if suggestion != test.suggestion {
+			t.Errorf("autosuggestion(%q, %d) = %q, expected %q", test.text, test.position, suggestion, test.suggestion)
+		}
+	}
+
+	// suggestions are disabled or are not supported by the terminal
+	terminal, restore := suggestTerminal(t, "SELECT * FROM users;")
+	defer restore()
+	terminal.Suggestions = false
+	if suggestion := autosuggestion(terminal, testBuffer("SELECT", 6)); suggestion != "" {
+		t.Errorf("disabled suggestions: %q", suggestion)
+	}
+	terminal.Suggestions = true
+	delete(terminal.TermInfo.Strings, "dim")
+	if suggestion := autosuggestion(terminal, testBuffer("SELECT", 6)); suggestion != "" {
+		t.Errorf("suggestion without dim: %q", suggestion)
+	}
+}
+
+func TestAcceptSuggestion(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    func(*Terminal, *SqlCommandBuffer)
+		position   uint64
+		text       string
+		cursor     uint64
+		suggestion string
+	}{
+		// Right and End accept the suggestion and Alt-F its next word
+		{"moveRight", moveRight, 8, "SELECT * FROM users;", 20, ""},
+		{"endOfLine", endOfLine, 8, "SELECT * FROM users;", 20, ""},
+		{"forwardWord", forwardWord, 8, "SELECT * FROM", 13, " users;"},
+		// other keys move the cursor and hide the suggestion
+		{"moveLeft", moveLeft, 8, "SELECT *", 7, ""},
+		{"beginningOfLine", beginningOfLine, 8, "SELECT *", 0, ""},
+		// the suggestion is accepted at the end of the line only
+		{"moveRight", moveRight, 7, "SELECT *", 8, " FROM users;"},
+		{"endOfLine", endOfLine, 0, "SELECT *", 8, " FROM users;"},
+		{"forwardWord", forwardWord, 0, "SELECT *", 6, ""},
+	}
+	for _, test := range tests {
+		terminal, restore := suggestTerminal(t, "SELECT * FROM users;")
+		cmd := testBuffer("SELECT *", test.position)
+		refreshLine(terminal, cmd)
+		test.command(terminal, cmd)
+		restore()
+		if cmd.String() != test.text || cmd.Position != test.cursor || terminal.suggestion != test.suggestion {
+			t.Errorf("%s at %d: %q at %d with %q, expected %q at %d with %q", test.name, test.position,
+				cmd.String(), cmd.Position, terminal.suggestion, test.text, test.cursor, test.suggestion)
+		}
+	}
+}
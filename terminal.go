@@ -2,7 +2,13 @@ package main
 
 import (
 	"os"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/0xAX/mysql-tools/history"
+	"github.com/0xAX/mysql-tools/mysql"
+	"github.com/0xAX/mysql-tools/sql"
 	"github.com/0xAX/mysql-tools/terminfo"
 	"github.com/0xAX/mysql-tools/termios"
 )
@@ -14,6 +20,7 @@ const (
 	CTRL_D    = 4   // Ctrl-d
 	CTRL_E    = 5   // Ctrl-e
 	CTRL_F    = 6   // Ctrl-f
+	CTRL_G    = 7   // Ctrl-g
 	CTRL_H    = 8   // Ctrl-h
 	TAB       = 9   // Tab
 	CTRL_K    = 11  // Ctrl+k
@@ -21,9 +28,12 @@ const (
 	ENTER     = 13  // Enter
 	CTRL_N    = 14  // Ctrl-n
 	CTRL_P    = 16  // Ctrl-p
+	CTRL_R    = 18  // Ctrl-r
+	CTRL_S    = 19  // Ctrl-s
 	CTRL_T    = 20  // Ctrl-t
 	CTRL_U    = 21  // Ctrl+u
 	CTRL_W    = 23  // Ctrl+w
+	CTRL_Y    = 25  // Ctrl+y
 	ESC       = 27  // Escape
 	BACKSPACE = 127 // Backspace
 	UP        = 'A' // Up
@@ -41,6 +51,49 @@ type Terminal struct {
 	TermCtrl *termios.Termios
 	// TermInfo provides TermInfo capabilities (see terminfo(5))
 	TermInfo *terminfo.Terminfo
+	// Input decodes keys typed in the terminal
+	Input *terminfo.KeyDecoder
+	// Conn is connection to the MySQL server
+	Conn *mysql.Conn
+	// History of entered statements
+	History *history.History
+	// KillRing keeps killed text
+	KillRing *KillRing
+	// Vi is state of the vi editing mode, nil in the emacs mode
+	Vi *ViMode
+	// Keymap maps key sequences to functions of the line editor
+	Keymap *Keymap
+	// Highlighter colors the input line, nil if colors are disabled
+	Highlighter *Highlighter
+	// AutoPairs enables insertion of the closing brackets and quotes
+	AutoPairs bool
+	// Suggestions enables suggestions of the history entries which
+	// start with the input
+	Suggestions bool
+	// splitter of the input keeps the current delimiter
+	splitter *sql.Splitter
+	// lastStatement is the last executed statement which is edited by
+	// \e with the empty buffer
+	lastStatement string
+	// prompt of the first edited line
+	prompt string
+	// bracket is offset of the highlighted matching bracket, -1 if
+	// there is no such bracket
+	bracket int
+	// bracketTimer removes the highlighting of the bracket
+	bracketTimer *time.Timer
+	// redraws is number of redraws of the edited lines, the bracket
+	// timer does nothing after a newer redraw
+	redraws int
+	// suggestion is the shown rest of the suggested history entry
+	suggestion string
+	// width of the terminal window
+	columns int
+	// row of the cursor relative to the first row of the edited lines
+	cursorRow int
+	// mutex serializes the key processing and the redrawing after
+	// resize of the terminal window
+	mutex sync.Mutex
 }
 
 // InitTerm collects information about the terminal session where
@@ -66,6 +119,11 @@ func InitTerm() (*Terminal, error) {
 	terminal.outputFd = outputFd
 	terminal.TermCtrl = termCtrl
 	terminal.TermInfo = termInfo
+	terminal.Input = termInfo.NewKeyDecoder(inputFd)
+	terminal.History = history.New("", "")
+	terminal.KillRing = NewKillRing()
+	terminal.Keymap = NewKeymap()
+	terminal.bracket = -1
 
 	return terminal, nil
 }
@@ -73,8 +131,8 @@ func InitTerm() (*Terminal, error) {
 // IoLoop is main loop of mysql-cli process. It handles all
 // input/output stuff.
 func (t *Terminal) IoLoop() error {
-	var c []byte = make([]byte, 1)
 	cmd := &SqlCommandBuffer{}
+	t.splitter = sql.NewSplitter()
 
 	// enable `noecho` as we will print symbols by ourself
 	err := termios.NoEcho(t.outputFd, true, t.TermCtrl)
@@ -91,84 +149,277 @@ func (t *Terminal) IoLoop() error {
 	// go to raw mode
 	termios.CfMakeRaw(t.outputFd, t.TermCtrl)
 
+	enableBracketedPaste(true)
+	t.printPrompt(DefaultPrompt + " ")
+
 	//
 	// start main reading loop
 	//
-mainloop:
+	t.watchResize(cmd)
 	for {
-		os.Stdin.Read(c)
-
-		switch c[0] {
-		case CTRL_C:
-			break mainloop
-		case BACKSPACE:
-			if cmd.Position == 0 || cmd.Length == 0 {
-				break
-			}
-			backspace(t, cmd)
-			break
-		case ENTER:
-			os.Stdout.Write([]byte("\n\r"))
-			cmd.Position += 1
-			cmd.Length += 1
-			break
-		case ESC:
-			os.Stdin.Read(c)
-			if c[0] == '[' {
-				os.Stdin.Read(c)
-				switch c[0] {
-				case UP:
-					/* TODO history */
-					break
-				case DOWN:
-					/* TODO history */
-					break
-				case RIGHT:
-					moveRight(t, cmd)
-					break
-				case LEFT:
-					moveLeft(t, cmd)
-					break
-				}
-			}
+		key, err := t.Input.ReadKey()
+		if err != nil {
 			break
-		default:
-			os.Stdout.Write(c)
-			cmd.Length += 1
-			cmd.Position += 1
+		}
+		t.mutex.Lock()
+		running := t.handleKey(cmd, key)
+		t.mutex.Unlock()
+		if !running {
 			break
 		}
 	}
 
+	enableBracketedPaste(false)
 	termios.Reset(t.outputFd, t.TermCtrl)
 
 	return nil
 }
 
+// handleKey processes the key. It returns false if mysql-cli must be
+// stopped.
+func (t *Terminal) handleKey(cmd *SqlCommandBuffer, key terminfo.Key) bool {
+	t.KillRing.Begin()
+	cmd.BeginUndo()
+	if t.Vi != nil && t.Vi.handle(t, cmd, []byte(key.Sequence)) {
+		return true
+	}
+
+	keys, binding, bound := t.Keymap.read(t.Input, key)
+	if !bound {
+		// other control symbols and unknown sequences are ignored
+		if keys[0] >= ' ' && keys[0] != BACKSPACE && !(t.AutoPairs && insertPair(t, cmd, keys)) {
+			insert(t, cmd, []byte(keys))
+		}
+		return true
+	}
+	if binding.function == nil {
+		insert(t, cmd, []byte(binding.macro))
+		return true
+	}
+	return binding.function(t, cmd)
+}
+
+// enter finishes the current line and executes the buffer. It
+// returns false if mysql-cli must be stopped.
+func (t *Terminal) enter(cmd *SqlCommandBuffer, splitter *sql.Splitter) bool {
+	cmd.Position = cmd.Length()
+	if t.bracket >= 0 || t.suggestion != "" {
+		// the line is left on the screen without the highlighting
+		// and the suggestion
+		drawLine(t, cmd, -1, "")
+	} else {
+		placeCursor(t, cmd)
+	}
+	// the cursor is on the next row already after the filled row
+	_, end := layout(linePrompts(t, cmd), lineText(cmd), int(cmd.Position-cmd.LineStart), t.columns)
+	if end.column > 0 {
+		os.Stdout.Write([]byte("\r\n"))
+	}
+	cmd.NewLine()
+	if t.Vi != nil {
+		t.Vi.Reset()
+	}
+	return t.executeBuffer(cmd, splitter)
+}
+
+// executeBuffer executes all terminated statements of the buffer and
+// prints the prompt of the next line. The unterminated rest of the
+// buffer is kept. A statement terminated by \e is opened in the editor
+// and the edited text is loaded with the following statements and the
+// rest instead of their execution. It returns false if mysql-cli must
+// be stopped.
+func (t *Terminal) executeBuffer(cmd *SqlCommandBuffer, splitter *sql.Splitter) bool {
+	text := cmd.String()
+	statements, rest := splitter.Split(text)
+	t.History.SetDatabase(t.Conn.Database())
+	edited, edit := "", false
+	for i, statement := range statements {
+		if statement.Terminator == "\\e" {
+			// the edited statement is followed by the rest of the
+			// input which is not executed
+			edited = editStatement(t, statement.Text)
+			for _, next := range statements[i+1:] {
+				edited += "\n" + next.Text + next.Terminator
+			}
+			if strings.TrimSpace(rest) != "" {
+				edited += "\n" + strings.TrimSpace(rest)
+			}
+			edit = true
+			break
+		}
+		if !t.execute(statement) {
+			return false
+		}
+	}
+
+	// the executed part of the buffer is saved as one history entry
+	err := t.History.Add(text[:len(text)-len(rest)])
+	if err != nil {
+		t.writeOutput("mysql-cli: [Warning] Failed to write history file: " + err.Error() + "\n")
+	}
+	if edit {
+		loadEdited(t, cmd, edited)
+		return true
+	}
+
+	if strings.TrimSpace(rest) == "" {
+		cmd.Reset("")
+		t.printPrompt(DefaultPrompt + " ")
+		return true
+	}
+
+	unterminated := splitter.Unterminated(rest)
+	if unterminated == "" && isQuitCommand(rest) {
+		return false
+	}
+	if rest != text {
+		cmd.Reset(rest)
+		t.printPrompt(Prompt(unterminated))
+		return true
+	}
+
+	// the statement is continued on the new line, its lines are edited
+	// together
+	cursor, _ := layout(linePrompts(t, cmd), lineText(cmd), int(cmd.Position-cmd.LineStart), t.columns)
+	t.cursorRow = cursor.row
+	os.Stdout.Write([]byte(Prompt(unterminated)))
+	return true
+}
+
+// printPrompt prints the prompt of the new line.
+func (t *Terminal) printPrompt(prompt string) {
+	t.prompt = prompt
+	t.cursorRow = 0
+	t.bracket = -1
+	t.suggestion = ""
+	os.Stdout.Write([]byte(t.promptText()))
+}
+
+// promptText returns the prompt of the first edited line with the
+// mode of the vi editing mode.
+func (t *Terminal) promptText() string {
+	if t.Vi != nil {
+		return t.Vi.modeIndicator() + t.prompt
+	}
+	return t.prompt
+}
+
+// refreshLine redraws the prompts and the edited lines and moves the
+// cursor to the current position.
+func refreshLine(t *Terminal, cmd *SqlCommandBuffer) {
+	drawLine(t, cmd, matchingBracket(t, cmd), autosuggestion(t, cmd))
+}
+
+// drawLine redraws the edited lines with the highlighted bracket at
+// the given offset of the buffer, -1 if there is no such bracket, and
+// with the suggestion after the end of the line.
+func drawLine(t *Terminal, cmd *SqlCommandBuffer, bracket int, suggestion string) {
+	start, end := int(cmd.LineStart), int(cmd.Length())
+	display := displayText(t, cmd, start, end)
+	if bracket >= 0 {
+		next := int(cmd.NextSymbol(uint64(bracket)))
+		style, reset := bracketStyle(t)
+		display = displayText(t, cmd, start, bracket) + style + displayText(t, cmd, bracket, next) + reset + displayText(t, cmd, next, end)
+	}
+	text := lineText(cmd)
+	if suggestion != "" {
+		style, reset := suggestionStyle(t)
+		// lines of the suggestion are shown on one line
+		shown := displayReplacer.Replace(strings.Replace(suggestion, "\n", " ", -1))
+		text += shown
+		display += style + shown + reset
+	}
+	t.render(linePrompts(t, cmd), text, display, int(cmd.Position-cmd.LineStart))
+	t.bracket = bracket
+	t.suggestion = suggestion
+	hideBracketLater(t, cmd)
+}
+
+// displayText returns the text of the buffer between the given offsets
+// as it is shown with the syntax highlighting.
+func displayText(t *Terminal, cmd *SqlCommandBuffer, from int, to int) string {
+	if t.Highlighter != nil && t.splitter != nil {
+		return t.Highlighter.Highlight(cmd.String(), from, to, t.splitter.Delimiter)
+	}
+	return displayReplacer.Replace(string(cmd.Text[from:to]))
+}
+
+// placeCursor moves the cursor to the current position. The line is
+// redrawn if the highlighted matching bracket or the suggestion is
+// changed.
+func placeCursor(t *Terminal, cmd *SqlCommandBuffer) {
+	bracket, suggestion := matchingBracket(t, cmd), autosuggestion(t, cmd)
+	if bracket != t.bracket || suggestion != t.suggestion {
+		drawLine(t, cmd, bracket, suggestion)
+		return
+	}
+	t.moveCursor(linePrompts(t, cmd), lineText(cmd), int(cmd.Position-cmd.LineStart))
+}
+
+// historyPrevious moves the cursor to the previous line of the
+// statement. On the first line it replaces the edited lines with the
+// previous entry of the history.
+func historyPrevious(t *Terminal, cmd *SqlCommandBuffer) {
+	if previousLine(t, cmd) {
+		return
+	}
+	entry, ok := t.History.Previous(cmd.Line())
+	if !ok {
+		return
+	}
+	cmd.SetLine(entry)
+	refreshLine(t, cmd)
+}
+
+// historyNext moves the cursor to the next line of the statement. On
+// the last line it replaces the edited lines with the next entry of
+// the history or with the lines which were edited before navigation.
+func historyNext(t *Terminal, cmd *SqlCommandBuffer) {
+	if nextLine(t, cmd) {
+		return
+	}
+	entry, ok := t.History.Next()
+	if !ok {
+		return
+	}
+	cmd.SetLine(entry)
+	refreshLine(t, cmd)
+}
+
+// insert inserts the symbol at the current position and redraws the
+// line.
+func insert(t *Terminal, cmd *SqlCommandBuffer, c []byte) {
+	cmd.Insert(c)
+	refreshLine(t, cmd)
+}
+
 func backspace(t *Terminal, cmd *SqlCommandBuffer) {
-	capability, _ := t.TermInfo.ApplyCapability("cub1")
-	os.Stdout.Write([]byte(capability))
-	os.Stdout.Write([]byte(" "))
-	capability, _ = t.TermInfo.ApplyCapability("cub1")
-	os.Stdout.Write([]byte(capability))
-	cmd.Position -= 1
-	cmd.Length -= 1
+	if t.AutoPairs && isEmptyPair(cmd) {
+		cmd.Remove(cmd.Position-1, cmd.Position+1)
+		refreshLine(t, cmd)
+		return
+	}
+	if !cmd.Delete() {
+		return
+	}
+	refreshLine(t, cmd)
 }
 
 func moveRight(t *Terminal, cmd *SqlCommandBuffer) {
-	if cmd.Position == cmd.Length {
+	if acceptSuggestion(t, cmd, false) {
+		return
+	}
+	if cmd.Position == cmd.Length() {
 		return
 	}
-	cmd.Position += 1
-	capability, _ := t.TermInfo.ApplyCapability("cuf1")
-	os.Stdout.Write([]byte(capability))
+	cmd.Position = cmd.NextSymbol(cmd.Position)
+	placeCursor(t, cmd)
 }
 
 func moveLeft(t *Terminal, cmd *SqlCommandBuffer) {
-	if cmd.Position == 0 {
+	if cmd.Position == cmd.LineStart {
 		return
 	}
-	cmd.Position -= 1
-	capability, _ := t.TermInfo.ApplyCapability("cub1")
-	os.Stdout.Write([]byte(capability))
+	cmd.Position = cmd.PreviousSymbol(cmd.Position)
+	placeCursor(t, cmd)
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/0xAX/mysql-tools/history"
+	"github.com/0xAX/mysql-tools/terminfo"
+	"github.com/0xAX/mysql-tools/termios"
+)
+
+// testTerminal returns the terminal which reads the keys from the
+// standard input and discards its output. The input is not closed, so
+// waiting for the rest of escape sequences times out after the keys.
+// The returned function restores the standard input and output.
+func testTerminal(t *testing.T, keys string) (*Terminal, func()) {
+	input, output, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := output.Write([]byte(keys)); err != nil {
+		t.Fatal(err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdin, stdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = input, devNull
+	terminal := &Terminal{
+		outputFd: devNull,
+		TermCtrl: &termios.Termios{},
+		TermInfo: &terminfo.Terminfo{Bools: map[string]bool{}, Numbers: map[string]uint16{}, Strings: map[string]string{}},
+		History:  history.New("", ""),
+		KillRing: NewKillRing(),
+		prompt:   DefaultPrompt + " ",
+		columns:  defaultColumns,
+	}
+	terminal.Input = terminal.TermInfo.NewKeyDecoder(input)
+	return terminal, func() {
+		os.Stdin, os.Stdout = stdin, stdout
+		input.Close()
+		output.Close()
+		devNull.Close()
+	}
+}
+
+// unreadInput returns the keys which are left in the input.
+func unreadInput(terminal *Terminal) string {
+	rest := make([]byte, 64)
+	n := 0
+	if terminal.Input.Wait(0) {
+		n, _ = terminal.Input.Read(rest)
+	}
+	return string(rest[:n])
+}
+
+// testBuffer returns the buffer with the text and the cursor at the
+// given position.
+func testBuffer(text string, position uint64) *SqlCommandBuffer {
+	cmd := &SqlCommandBuffer{}
+	cmd.Insert([]byte(text))
+	cmd.Position = position
+	return cmd
+}
@@ -0,0 +1,356 @@
+package terminfo
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Modifiers of keys
+type Modifier int
+
+const (
+	ModShift Modifier = 1 << iota // Shift
+	ModAlt                        // Alt (Escape prefix or xterm modifier)
+	ModCtrl                       // Ctrl
+	ModMeta                       // Meta
+)
+
+// EscapeTimeout is default time to wait for the rest of an escape
+// sequence after Escape
+const EscapeTimeout = 50 * time.Millisecond
+
+// Key is a key event decoded from the input.
+type Key struct {
+	// Name of a special key (Up, Home, F1 and etc.) or "Escape" for a
+	// lone Escape, empty for symbols and unknown sequences
+	Name string
+	// Modifiers of the key
+	Modifiers Modifier
+	// Text is the typed symbol, empty for special keys
+	Text string
+	// Sequence is the bytes of the key as they are read
+	Sequence string
+}
+
+// String returns name of the key with modifiers, e.g. Ctrl-Left or
+// Alt-b.
+func (key Key) String() string {
+	name := key.Name
+	if name == "" {
+		name = key.Text
+	}
+	for _, modifier := range []struct {
+		modifier Modifier
+		name     string
+	}{{ModMeta, "Meta-"}, {ModShift, "Shift-"}, {ModAlt, "Alt-"}, {ModCtrl, "Ctrl-"}} {
+		if key.Modifiers&modifier.modifier != 0 {
+			name = modifier.name + name
+		}
+	}
+	return name
+}
+
+// keyCapabilities are names of keys sent by the key capabilities of
+// terminfo (see terminfo(5)). The function keys kf0..kf63 are added
+// separately.
+var keyCapabilities = map[string]Key{
+	"kcuu1": {Name: "Up"},
+	"kcud1": {Name: "Down"},
+	"kcub1": {Name: "Left"},
+	"kcuf1": {Name: "Right"},
+	"khome": {Name: "Home"},
+	"kend":  {Name: "End"},
+	"kbeg":  {Name: "Begin"},
+	"kich1": {Name: "Insert"},
+	"kdch1": {Name: "Delete"},
+	"kpp":   {Name: "PageUp"},
+	"knp":   {Name: "PageDown"},
+	"kcbt":  {Name: "Tab", Modifiers: ModShift},
+	"kent":  {Name: "Enter"},
+	"kLFT":  {Name: "Left", Modifiers: ModShift},
+	"kRIT":  {Name: "Right", Modifiers: ModShift},
+	"kHOM":  {Name: "Home", Modifiers: ModShift},
+	"kEND":  {Name: "End", Modifiers: ModShift},
+	"kIC":   {Name: "Insert", Modifiers: ModShift},
+	"kDC":   {Name: "Delete", Modifiers: ModShift},
+	"kPRV":  {Name: "PageUp", Modifiers: ModShift},
+	"kNXT":  {Name: "PageDown", Modifiers: ModShift},
+	"kind":  {Name: "Down", Modifiers: ModShift},
+	"kri":   {Name: "Up", Modifiers: ModShift},
+}
+
+// csiKeys are names of keys sent by xterm compatible terminals as
+// ESC [ 1 ; modifiers final or ESC O final.
+var csiKeys = map[byte]string{
+	'A': "Up",
+	'B': "Down",
+	'C': "Right",
+	'D': "Left",
+	'E': "Begin",
+	'F': "End",
+	'H': "Home",
+	'P': "F1",
+	'Q': "F2",
+	'R': "F3",
+	'S': "F4",
+}
+
+// tildeKeys are names of keys sent as ESC [ number ; modifiers ~.
+var tildeKeys = map[int]string{
+	1: "Home", 2: "Insert", 3: "Delete", 4: "End", 5: "PageUp", 6: "PageDown",
+	7: "Home", 8: "End", 11: "F1", 12: "F2", 13: "F3", 14: "F4", 15: "F5",
+	17: "F6", 18: "F7", 19: "F8", 20: "F9", 21: "F10", 23: "F11", 24: "F12",
+}
+
+// keyNode is a node of the prefix tree of key sequences.
+type keyNode struct {
+	children map[byte]*keyNode
+	key      *Key
+}
+
+// KeyDecoder decodes bytes of the input into key events. Sequences of
+// the terminal are recognized with the key capabilities of terminfo
+// and the xterm encoding of modifiers.
+type KeyDecoder struct {
+	// Timeout is time to wait for the rest of an escape sequence
+	Timeout time.Duration
+	input   *os.File
+	root    *keyNode
+	// bytes which are read, but not decoded yet
+	buffer []byte
+}
+
+// NewKeyDecoder returns decoder of keys of the terminal which are
+// read from the input.
+func (terminfo *Terminfo) NewKeyDecoder(input *os.File) *KeyDecoder {
+	decoder := &KeyDecoder{Timeout: EscapeTimeout, input: input, root: &keyNode{}}
+	for capability, key := range keyCapabilities {
+		decoder.add(terminfo.Strings[capability], key)
+	}
+	for i := 0; i <= 63; i++ {
+		decoder.add(terminfo.Strings["kf"+strconv.Itoa(i)], Key{Name: "F" + strconv.Itoa(i)})
+	}
+	return decoder
+}
+
+// add adds the key sequence to the prefix tree. Sequences of one byte
+// (e.g. kbs) are decoded as symbols.
+func (d *KeyDecoder) add(sequence string, key Key) {
+	if len(sequence) < 2 {
+		return
+	}
+	node := d.root
+	for i := 0; i < len(sequence); i++ {
+		if node.children == nil {
+			node.children = map[byte]*keyNode{}
+		}
+		child, ok := node.children[sequence[i]]
+		if !ok {
+			child = &keyNode{}
+			node.children[sequence[i]] = child
+		}
+		node = child
+	}
+	key.Sequence = sequence
+	node.key = &key
+}
+
+// ReadKey reads the next key. After Escape the rest of the sequence
+// is awaited for Timeout, so a lone Escape is not mixed up with the
+// following keys.
+func (d *KeyDecoder) ReadKey() (Key, error) {
+	for {
+		key, n := d.Decode(d.buffer, false)
+		if n > 0 {
+			d.buffer = d.buffer[n:]
+			return key, nil
+		}
+		if len(d.buffer) > 0 && !d.poll(d.Timeout) {
+			key, n = d.Decode(d.buffer, true)
+			d.buffer = d.buffer[n:]
+			return key, nil
+		}
+		err := d.fill()
+		if err != nil {
+			return Key{}, err
+		}
+	}
+}
+
+// Read reads the input without decoding, e.g. pasted text. Bytes
+// which are read by ReadKey, but are not decoded yet are returned
+// first.
+func (d *KeyDecoder) Read(p []byte) (int, error) {
+	if len(d.buffer) > 0 {
+		n := copy(p, d.buffer)
+		d.buffer = d.buffer[n:]
+		return n, nil
+	}
+	return d.input.Read(p)
+}
+
+// Wait returns true if the input is available in the timeout.
+func (d *KeyDecoder) Wait(timeout time.Duration) bool {
+	return len(d.buffer) > 0 || d.poll(timeout)
+}
+
+// poll returns true if the input file is readable in the timeout.
+func (d *KeyDecoder) poll(timeout time.Duration) bool {
+	fd := int(d.input.Fd())
+	set := &syscall.FdSet{}
+	bits := int(unsafe.Sizeof(set.Bits[0])) * 8
+	set.Bits[fd/bits] |= 1 << uint(fd%bits)
+	timeval := syscall.NsecToTimeval(timeout.Nanoseconds())
+	n, err := syscall.Select(fd+1, set, nil, nil, &timeval)
+	return err == nil && n > 0
+}
+
+// fill reads available bytes of the input to the buffer.
+func (d *KeyDecoder) fill() error {
+	data := make([]byte, 64)
+	n, err := d.input.Read(data)
+	if n == 0 && err == nil {
+		err = io.EOF
+	}
+	d.buffer = append(d.buffer, data[:n]...)
+	return err
+}
+
+// Decode decodes the key at the beginning of the data and returns it
+// with the number of decoded bytes. It returns 0 if the data is a
+// prefix of a longer sequence, unless final is true, which means that
+// no more bytes follow in time.
+func (d *KeyDecoder) Decode(data []byte, final bool) (Key, int) {
+	if len(data) == 0 {
+		return Key{}, 0
+	}
+
+	// the longest sequence of terminfo
+	node := d.root
+	var match *Key
+	for i := 0; i < len(data); i++ {
+		node = node.children[data[i]]
+		if node == nil {
+			break
+		}
+		if node.key != nil {
+			match = node.key
+		}
+		if i == len(data)-1 && node.children != nil && !final {
+			return Key{}, 0
+		}
+	}
+	if match != nil {
+		return *match, len(match.Sequence)
+	}
+
+	if data[0] != 0x1b {
+		return decodeSymbol(data, final)
+	}
+	if len(data) == 1 {
+		if !final {
+			return Key{}, 0
+		}
+		return Key{Name: "Escape", Sequence: "\x1b"}, 1
+	}
+
+	// the sequences return -1 if the data is not such sequence
+	var key Key
+	n := -1
+	switch data[1] {
+	case '[':
+		key, n = decodeCSI(data)
+	case 'O':
+		key, n = decodeSS3(data)
+	}
+	if n > 0 || n == 0 && !final {
+		return key, n
+	}
+
+	// Escape followed by a key is the key with Alt
+	key, n = d.Decode(data[1:], final)
+	if n == 0 {
+		return key, 0
+	}
+	key.Modifiers |= ModAlt
+	key.Sequence = string(data[:n+1])
+	return key, n + 1
+}
+
+// decodeSymbol decodes UTF-8 encoded symbol or a control character.
+func decodeSymbol(data []byte, final bool) (Key, int) {
+	if !utf8.FullRune(data) && !final {
+		return Key{}, 0
+	}
+	_, n := utf8.DecodeRune(data)
+	return Key{Text: string(data[:n]), Sequence: string(data[:n])}, n
+}
+
+// decodeCSI decodes ESC [ parameters final-byte sequences. Unknown
+// sequences are decoded as keys without name and text, so they are
+// not mixed up with typed symbols. It returns 0 if the sequence is
+// not complete.
+func decodeCSI(data []byte) (Key, int) {
+	i := 2
+	for i < len(data) && data[i] >= 0x20 && data[i] <= 0x3f {
+		i++
+	}
+	if i == len(data) {
+		return Key{}, 0
+	}
+	if data[i] < 0x40 || data[i] > 0x7e {
+		return Key{}, -1
+	}
+	sequence := string(data[:i+1])
+	parameters := strings.Split(string(data[2:i]), ";")
+	key := Key{Sequence: sequence}
+
+	switch {
+	case data[i] == '~':
+		number, _ := strconv.Atoi(parameters[0])
+		key.Name = tildeKeys[number]
+	case data[i] == 'Z':
+		key.Name = "Tab"
+		key.Modifiers = ModShift
+	default:
+		key.Name = csiKeys[data[i]]
+	}
+	if key.Name != "" && len(parameters) > 1 {
+		key.Modifiers |= xtermModifiers(parameters[1])
+	}
+	return key, len(sequence)
+}
+
+// decodeSS3 decodes ESC O final-byte sequences which are sent in the
+// keypad transmit mode. Some terminals send modifiers before the
+// final byte.
+func decodeSS3(data []byte) (Key, int) {
+	i := 2
+	for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+		i++
+	}
+	if i == len(data) {
+		return Key{}, 0
+	}
+	name, ok := csiKeys[data[i]]
+	if !ok {
+		return Key{}, -1
+	}
+	return Key{Name: name, Modifiers: xtermModifiers(string(data[2:i])), Sequence: string(data[:i+1])}, i + 1
+}
+
+// xtermModifiers decodes the modifiers parameter of xterm, which is
+// 1 + bits of Shift (1), Alt (2), Ctrl (4) and Meta (8).
+func xtermModifiers(parameter string) Modifier {
+	value, err := strconv.Atoi(parameter)
+	if err != nil || value < 1 {
+		return 0
+	}
+	return Modifier(value - 1)
+}
@@ -0,0 +1,68 @@
+package terminfo
+
+import "testing"
+
+func testDecoder() *KeyDecoder {
+	terminfo := &Terminfo{Strings: map[string]string{
+		"kcuu1": "\x1bOA",
+		"khome": "\x1bOH",
+		"kdch1": "\x1b[3~",
+		"kf1":   "\x1bOP",
+		"kf13":  "\x1b[1;2P",
+		"kLFT":  "\x1b[1;2D",
+		"kbs":   "\x7f",
+	}}
+	return terminfo.NewKeyDecoder(nil)
+}
+
+func TestDecode(t *testing.T) {
+	decoder := testDecoder()
+	tests := []struct {
+		data     string
+		name     string
+		text     string
+		length   int
+		consumed int
+	}{
+		{"\x1bOA", "Up", "", 3, 3},
+		{"\x1b[A", "Up", "", 3, 3},
+		{"\x1b[3~x", "Delete", "", 4, 4},
+		{"\x1bOPx", "F1", "", 3, 3},
+		{"\x1b[1;2P", "F13", "", 6, 6},
+		{"\x1b[1;5C", "Ctrl-Right", "", 6, 6},
+		{"\x1b[1;2D", "Shift-Left", "", 6, 6},
+		{"\x1b[5;3~", "Alt-PageUp", "", 6, 6},
+		{"\x1b[Z", "Shift-Tab", "", 3, 3},
+		{"\x1bb", "Alt-b", "b", 2, 2},
+		{"\x1b\x1b[A", "Alt-Up", "", 4, 4},
+		{"\x1b[200~", "", "", 6, 6},
+		{"éx", "é", "é", 2, 2},
+		{"\x7f", "\x7f", "\x7f", 1, 1},
+	}
+	for _, test := range tests {
+		key, n := decoder.Decode([]byte(test.data), false)
+		if n != test.consumed || key.String() != test.name || key.Text != test.text || len(key.Sequence) != test.length {
+			t.Errorf("Decode(%q) failed: %q %d", test.data, key, n)
+		}
+	}
+}
+
+func TestDecodeIncomplete(t *testing.T) {
+	decoder := testDecoder()
+	for _, data := range []string{"\x1b", "\x1bO", "\x1b[", "\x1b[1;5", "\xc3"} {
+		_, n := decoder.Decode([]byte(data), false)
+		if n != 0 {
+			t.Errorf("Decode(%q) failed (incomplete)", data)
+		}
+	}
+
+	// no more bytes follow after the timeout
+	key, n := decoder.Decode([]byte("\x1b"), true)
+	if n != 1 || key.Name != "Escape" {
+		t.Error("Decode failed (lone Escape)", key)
+	}
+	key, n = decoder.Decode([]byte("\x1bO"), true)
+	if n != 2 || key.String() != "Alt-O" {
+		t.Error("Decode failed (Alt-O)", key)
+	}
+}
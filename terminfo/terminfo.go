@@ -45,6 +45,10 @@ const terminfoHeaderLength = 12
 const terminfoMagic1 = 0x1a
 const terminfoMagic2 = 0x01
 
+// magic header of the extended terminfo format with 32-bit numbers
+const terminfoExtendedMagic1 = 0x1e
+const terminfoExtendedMagic2 = 0x02
+
 // zero in ASCII
 const zeroASCII = 48
 
@@ -141,7 +145,10 @@ func parseTerminfo(terminfoContent []byte) (*Terminfo, error) {
 	terminfo.Strings = make(map[string]string)
 
 	// check magic header of a terminfo file
-	if terminfoContent[0] != terminfoMagic1 && terminfoContent[1] != terminfoMagic2 {
+	numberSize := 2
+	if terminfoContent[0] == terminfoExtendedMagic1 && terminfoContent[1] == terminfoExtendedMagic2 {
+		numberSize = 4
+	} else if terminfoContent[0] != terminfoMagic1 && terminfoContent[1] != terminfoMagic2 {
 		return nil, &errorTerminfo{"wrong terminfo file"}
 	}
 
@@ -172,14 +179,27 @@ func parseTerminfo(terminfoContent []byte) (*Terminfo, error) {
 
 	// parse numbers
 	numbericIndex := 0
-	for i := 0; i < int(shortIntsSectionSize*2); i += 2 {
+	for i := 0; i < int(shortIntsSectionSize)*numberSize; i += numberSize {
 		val := binary.LittleEndian.Uint16(terminfoContent[offset+i : int(offset+i+2)])
+		if numberSize == 4 {
+			// negative numbers mean absent or cancelled capabilities
+			// and the positive ones which do not fit are saturated
+			val32 := int32(binary.LittleEndian.Uint32(terminfoContent[offset+i : int(offset+i+4)]))
+			switch {
+			case val32 < 0:
+				val = 0xffff
+			case val32 > 0x7fff:
+				val = 0x7fff
+			default:
+				val = uint16(val32)
+			}
+		}
 		if val != 0 && val != 0x3737 && val != 0xffff {
 			terminfo.Numbers[GetTerminfoNumericCodes()[numbericIndex]] = val
 		}
 		numbericIndex++
 	}
-	offset += int(2 * shortIntsSectionSize)
+	offset += int(shortIntsSectionSize) * numberSize
 	// parse strings
 	stringTableStart := offset + int(offsetsNumber*2)
 	termCapabilityStr := ""
@@ -238,11 +258,22 @@ func (terminfo *Terminfo) ApplyCapability(capability string, args ...interface{}
 					state = initial
 					break
 				case 'c':
-					value := stack.Pop()
-					if value == 0 {
-						result += string(0x80)
-					} else {
-						result += string(value.(uint8))
+					// zero is sent as 0x80 like ncurses does it
+					switch value := stack.Pop().(type) {
+					case int:
+						if value == 0 {
+							value = 0x80
+						}
+						result += string(rune(value))
+					case uint8:
+						if value == 0 {
+							value = 0x80
+						}
+						result += string(rune(value))
+					case string:
+						result += value
+					default:
+						return "", &errorTerminfo{"%c needs a number or a char"}
 					}
 					state = initial
 					break
@@ -261,8 +292,9 @@ func (terminfo *Terminfo) ApplyCapability(capability string, args ...interface{}
 					break
 				case '<', 'O', 'A', '=', '>':
 					set := 0
-					v1 := stack.Pop().(int)
+					// the second operand is on the top of the stack
 					v2 := stack.Pop().(int)
+					v1 := stack.Pop().(int)
 					switch cur {
 					case 'O':
 						if v1 > 0 || v2 > 0 {
@@ -303,16 +335,9 @@ func (terminfo *Terminfo) ApplyCapability(capability string, args ...interface{}
 					break
 				case '\'':
 					state = charStart
-				case '+':
-				case '-':
-				case '/':
-				case '*':
-				case '^':
-				case '&':
-				case '|':
-				case 'm':
-					v1 := stack.Pop().(int)
+				case '+', '-', '/', '*', '^', '&', '|', 'm':
 					v2 := stack.Pop().(int)
+					v1 := stack.Pop().(int)
 					switch cur {
 					case '+':
 						stack.Push(v1 + v2)
@@ -339,6 +364,7 @@ func (terminfo *Terminfo) ApplyCapability(capability string, args ...interface{}
 						stack.Push(v1 % v2)
 						break
 					}
+					state = initial
 					break
 				case '!':
 					value := stack.Pop()
@@ -347,24 +373,29 @@ func (terminfo *Terminfo) ApplyCapability(capability string, args ...interface{}
 					} else {
 						stack.Push(1)
 					}
+					state = initial
 					break
 				case '~':
 					value := stack.Pop()
 					stack.Push(^value.(int))
+					state = initial
 					break
 				case '{':
 					state = integerParameter
 					break
 				case 'i':
-					args[0] = args[0].(int) + 1
-					args[1] = args[1].(int) + 1
+					// increment the first two parameters if they are given
+					for i := 0; i < len(args) && i < 2; i++ {
+						if value, ok := args[i].(int); ok {
+							args[i] = value + 1
+						}
+					}
 					state = initial
 					break
 				case ':':
 					state = parseFlags
 					break
-				case ';':
-				case '?':
+				case ';', '?':
 					state = initial
 					break
 				case 't':
@@ -418,20 +449,19 @@ func (terminfo *Terminfo) ApplyCapability(capability string, args ...interface{}
 					strNumber = ""
 					state = initial
 				} else {
-					i := strconv.Itoa(int(64 - cur - 9))
-					strNumber += i
+					strNumber += string(cur)
 				}
 				break
 			case getParam:
 				if cur >= 'a' && cur <= 'z' {
-					cur = cur - 97
+					cur = cur - 'a'
 					stack.Push(variables.dynamic[cur])
 					state = initial
 					continue
 				}
 
 				if cur >= 'A' && cur <= 'Z' {
-					cur = cur - 66
+					cur = cur - 'A'
 					stack.Push(variables.static[cur])
 					state = initial
 					continue
@@ -440,14 +470,14 @@ func (terminfo *Terminfo) ApplyCapability(capability string, args ...interface{}
 			case setParam:
 				value := stack.Pop()
 				if cur >= 'a' && cur <= 'z' {
-					cur = cur - 97
+					cur = cur - 'a'
 					variables.dynamic[cur] = value.(int)
 					state = initial
 					continue
 				}
 
 				if cur >= 'A' && cur <= 'Z' {
-					cur = cur - 66
+					cur = cur - 'A'
 					variables.static[cur] = value.(int)
 					state = initial
 					continue
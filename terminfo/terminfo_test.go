@@ -4,6 +4,8 @@
 package terminfo
 
 import (
+	"bytes"
+	"encoding/binary"
 	"os"
 	"testing"
 )
@@ -52,3 +54,82 @@ func TestParseTerminfo(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyCapability(t *testing.T) {
+	terminfo := &Terminfo{Strings: map[string]string{
+		"setaf": "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+		"cup":   "\x1b[%i%p1%d;%p2%dH",
+		"cub":   "\x1b[%p1%dD",
+		"chr":   "%p1%c",
+		"quote": "%'x'%c",
+	}}
+	tests := []struct {
+		capability string
+		args       []interface{}
+		expected   string
+	}{
+		{"setaf", []interface{}{1}, "\x1b[31m"},
+		{"setaf", []interface{}{7}, "\x1b[37m"},
+		{"setaf", []interface{}{8}, "\x1b[90m"},
+		{"setaf", []interface{}{12}, "\x1b[94m"},
+		{"setaf", []interface{}{16}, "\x1b[38;5;16m"},
+		{"setaf", []interface{}{208}, "\x1b[38;5;208m"},
+		{"cup", []interface{}{0, 0}, "\x1b[1;1H"},
+		{"cup", []interface{}{4, 9}, "\x1b[5;10H"},
+		{"cub", []interface{}{3}, "\x1b[3D"},
+		{"chr", []interface{}{65}, "A"},
+		{"chr", []interface{}{uint8(66)}, "B"},
+		{"chr", []interface{}{0}, string(rune(0x80))},
+		{"quote", nil, "x"},
+	}
+	for _, test := range tests {
+		result, err := terminfo.ApplyCapability(test.capability, test.args...)
+		if err != nil || result != test.expected {
+			t.Errorf("ApplyCapability(%s, %v) failed: %q, expected %q", test.capability, test.args, result, test.expected)
+		}
+	}
+
+	terminfo.Strings["chr"] = "%p1%c"
+	if _, err := terminfo.ApplyCapability("chr", 1.5); err == nil {
+		t.Errorf("ApplyCapability(chr, 1.5) must fail")
+	}
+}
+
+func TestParseExtendedNumbers(t *testing.T) {
+	// the format of ncurses 6.1 with 32-bit numbers
+	names := "test|test terminal\x00"
+	content := &bytes.Buffer{}
+	content.Write([]byte{terminfoExtendedMagic1, terminfoExtendedMagic2})
+	binary.Write(content, binary.LittleEndian, []uint16{uint16(len(names)), 1, 14, 1, 4})
+	content.WriteString(names)
+	content.WriteByte(1)
+	numbers := make([]int32, 14)
+	for i := range numbers {
+		numbers[i] = -1
+	}
+	numbers[0] = 80       // cols
+	numbers[13] = 1 << 24 // colors
+	binary.Write(content, binary.LittleEndian, numbers)
+	binary.Write(content, binary.LittleEndian, uint16(0))
+	content.WriteString("\x1b[Z\x00")
+
+	result, err := parseTerminfo(content.Bytes())
+	if err != nil {
+		t.Fatal("parseTerminfo failed", err)
+	}
+	if result.Name != "test" {
+		t.Error("parseTerminfo failed (Name)", result.Name)
+	}
+	if result.Numbers["cols"] != 80 {
+		t.Error("parseTerminfo failed (cols)")
+	}
+	if result.Numbers["colors"] != 0x7fff {
+		t.Error("parseTerminfo failed (colors)")
+	}
+	if _, ok := result.Numbers["lines"]; ok {
+		t.Error("parseTerminfo failed (absent lines)")
+	}
+	if result.Strings["cbt"] != "\x1b[Z" {
+		t.Error("parseTerminfo failed (cbt)")
+	}
+}
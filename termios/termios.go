@@ -145,6 +145,25 @@ func TcFlow(fd *os.File, action int) error {
 	return nil
 }
 
+// WinSize describes size of a terminal window (see struct winsize
+// in ioctl_tty(2)).
+type WinSize struct {
+	Rows   uint16 // number of rows
+	Cols   uint16 // number of columns
+	XPixel uint16 // horizontal size in pixels (unused)
+	YPixel uint16 // vertical size in pixels (unused)
+}
+
+// TcGetWinSize returns size of the terminal window referred to by fd.
+func TcGetWinSize(fd *os.File) (*WinSize, error) {
+	winSize := &WinSize{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd.Fd(), uintptr(TIOCGWINSZ), uintptr(unsafe.Pointer(winSize)))
+	if errno != 0 {
+		return nil, &errorTermios{errno.Error()}
+	}
+	return winSize, nil
+}
+
 // CfGetOutputSpeed returns the output baud rate stored in the Termios
 // structure pointed to by *termios.
 func CfGetOutputSpeed(termios *Termios) (speed_t, error) {
@@ -196,7 +215,7 @@ func NoEcho(fd *os.File, enable bool, termios *Termios) error {
 	}
 
 	if enable == true {
-		termios.c_lflag ^= ECHO
+		termios.c_lflag &^= ECHO
 	} else {
 		termios.c_lflag |= ECHO
 	}
@@ -212,7 +231,7 @@ func Cbreak(fd *os.File, enable bool, termios *Termios) error {
 	}
 
 	if enable == true {
-		termios.c_lflag ^= ICANON
+		termios.c_lflag &^= ICANON
 	} else {
 		termios.c_lflag |= ICANON
 	}
@@ -36,3 +36,8 @@ const (
 	TCIOFF    = 2      // transmits a STOP character, which stops the terminal device from transmitting data to the system.
 	TCION     = 3      // transmits a START character, which starts the terminal device transmitting data to the system
 )
+
+// ioctl requests for the terminal window size
+const (
+	TIOCGWINSZ = 0x5413 // Get window size.
+)
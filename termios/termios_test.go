@@ -81,3 +81,19 @@ func TestTcgSetAttr(t *testing.T) {
 		t.Error("Reset failed")
 	}
 }
+
+func TestTcGetWinSize(t *testing.T) {
+	fd, err := os.OpenFile("/dev/tty", syscall.O_WRONLY|syscall.O_RDONLY, 0)
+	if err != nil {
+		t.Skip("OpenFile /dev/tty failed")
+	}
+	defer fd.Close()
+
+	winSize, err := TcGetWinSize(fd)
+	if err != nil {
+		t.Fatal("TcGetWinSize failed")
+	}
+	if winSize.Cols == 0 || winSize.Rows == 0 {
+		t.Error("Wrong window size")
+	}
+}
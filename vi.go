@@ -0,0 +1,524 @@
+package main
+
+import "unicode/utf8"
+
+// ViMode is state of the vi editing mode (--vi). The line editor is
+// in the insert mode after the start of each statement, Escape turns
+// on the normal (command) mode.
+type ViMode struct {
+	// true in the insert mode
+	insert bool
+	// count typed before a command, 0 if there is no count
+	count int
+	// pending operator (d, c or y) and its count
+	operator      byte
+	operatorCount int
+	// pending f, F, t or T motion which waits for a symbol and the
+	// bytes of the symbol which are typed
+	find   byte
+	symbol []byte
+	// text deleted or yanked by the last operator
+	register string
+	// keys of the current command, they are kept for "."
+	keys []byte
+	// true if the current command changes the line
+	change bool
+	// keys of the last change
+	lastChange []byte
+	// true while "." replays the last change
+	replaying bool
+}
+
+// NewViMode returns vi mode in the insert mode.
+func NewViMode() *ViMode {
+	return &ViMode{insert: true}
+}
+
+// modeIndicator returns the mode shown before the prompt like readline
+// does it with show-mode-in-prompt.
+func (v *ViMode) modeIndicator() string {
+	if v.insert {
+		return "(ins) "
+	}
+	return "(cmd) "
+}
+
+// Reset switches to the insert mode for the new statement.
+func (v *ViMode) Reset() {
+	v.insert = true
+	v.cancel()
+}
+
+// cancel drops pending count, operator and motion.
+func (v *ViMode) cancel() {
+	v.count = 0
+	v.operator = 0
+	v.operatorCount = 0
+	v.find = 0
+	v.symbol = nil
+	v.keys = nil
+	v.change = false
+}
+
+// handle processes the key in the vi mode. It returns false if the key
+// is processed by the common bindings of IoLoop (Enter, Ctrl-C, arrows
+// and etc. and all keys of the insert mode except Escape).
+func (v *ViMode) handle(t *Terminal, cmd *SqlCommandBuffer, key []byte) bool {
+	c := key[0]
+	if v.insert {
+		// escape sequences of arrows and pasted text are processed
+		// by IoLoop
+		if c == ESC && len(key) > 1 {
+			return false
+		}
+		if c != ESC {
+			if v.change {
+				v.keys = append(v.keys, key...)
+			}
+			return false
+		}
+		v.keys = append(v.keys, c)
+		v.finishChange(cmd)
+		v.insert = false
+		if start, _ := cmd.LineBounds(cmd.Position); cmd.Position > start {
+			cmd.Position = cmd.PreviousSymbol(cmd.Position)
+		}
+		refreshLine(t, cmd)
+		return true
+	}
+
+	switch c {
+	case ENTER, CTRL_C, CTRL_D, CTRL_L, CTRL_S:
+		v.cancel()
+		return false
+	case ESC:
+		// Escape cancels the pending command, escape sequences of
+		// arrows and etc. are processed by IoLoop
+		v.cancel()
+		return len(key) == 1
+	}
+	v.keys = append(v.keys, key...)
+	for _, c := range key {
+		v.normal(t, cmd, c)
+	}
+	if v.count == 0 && v.operator == 0 && v.find == 0 && !v.insert {
+		v.finishChange(cmd)
+	}
+	refreshLine(t, cmd)
+	return true
+}
+
+// normal processes the key of the normal mode.
+func (v *ViMode) normal(t *Terminal, cmd *SqlCommandBuffer, c byte) {
+	if v.find != 0 {
+		// the symbol is awaited until all its bytes are typed
+		v.symbol = append(v.symbol, c)
+		if !utf8.FullRune(v.symbol) {
+			return
+		}
+		find, symbol := v.find, string(v.symbol)
+		v.find, v.symbol = 0, nil
+		v.motion(t, cmd, find, symbol)
+		return
+	}
+
+	switch c {
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		v.count = v.count*10 + int(c-'0')
+		return
+	case '0':
+		if v.count > 0 {
+			v.count *= 10
+			return
+		}
+		v.motion(t, cmd, c, "")
+	case 'h', 'l', '^', '$', 'w', 'b', 'e', BACKSPACE, ' ':
+		v.motion(t, cmd, c, "")
+	case 'f', 'F', 't', 'T':
+		v.find = c
+		return
+	case 'd', 'c', 'y':
+		if v.operator == 0 {
+			v.operator = c
+			v.operatorCount = v.getCount()
+			v.count = 0
+			return
+		}
+		if v.operator == c {
+			// dd, cc and yy work with the current line, dd removes
+			// it with its new line
+			v.count = 0
+			start, end := cmd.LineBounds(cmd.Position)
+			if c == 'd' {
+				if end < cmd.Length() {
+					end++
+				} else if start > cmd.LineStart {
+					start--
+				}
+			}
+			v.apply(t, cmd, start, end)
+			if c == 'd' {
+				cmd.Position, _ = cmd.LineBounds(cmd.Position)
+			}
+			return
+		}
+		v.cancel()
+	case 'i', 'a', 'I', 'A':
+		cmd.BeginChange()
+		v.change = true
+		switch c {
+		case 'a':
+			cmd.Position = cmd.NextSymbol(cmd.Position)
+		case 'I':
+			cmd.Position, _ = cmd.LineBounds(cmd.Position)
+		case 'A':
+			_, cmd.Position = cmd.LineBounds(cmd.Position)
+		}
+		v.insert = true
+		v.count = 0
+	case 'x', 'X', 'D', 'C', 's', 'S':
+		// the shortcuts for operators with motions
+		command := map[byte]string{'x': "dl", 'X': "dh", 'D': "d$", 'C': "c$", 's': "cl", 'S': "cc"}[c]
+		count := v.getCount()
+		v.count = 0
+		v.operator = command[0]
+		v.operatorCount = count
+		v.normal(t, cmd, command[1])
+	case 'p', 'P':
+		v.put(cmd, c == 'p')
+	case 'u':
+		// a command is undone with the text inserted after it
+		for i := 0; i < v.getCount(); i++ {
+			cmd.Undo()
+		}
+		v.clamp(cmd)
+		v.cancel()
+	case CTRL_R:
+		for i := 0; i < v.getCount(); i++ {
+			cmd.Redo()
+		}
+		v.clamp(cmd)
+		v.cancel()
+	case '.':
+		v.repeat(t, cmd)
+	case 'k':
+		// the cursor is moved to the start of the recalled entry
+		if !previousLine(t, cmd) {
+			if entry, ok := t.History.Previous(cmd.Line()); ok {
+				cmd.SetLine(entry)
+				cmd.Position = cmd.LineStart
+			}
+		}
+		v.cancel()
+	case 'j':
+		if !nextLine(t, cmd) {
+			if entry, ok := t.History.Next(); ok {
+				cmd.SetLine(entry)
+				cmd.Position = cmd.LineStart
+			}
+		}
+		v.cancel()
+	case 'v':
+		cmd.BeginChange()
+		v.cancel()
+		editBuffer(t, cmd)
+		v.clamp(cmd)
+	default:
+		v.cancel()
+	}
+}
+
+// getCount returns the typed count or 1.
+func (v *ViMode) getCount() int {
+	if v.count == 0 {
+		return 1
+	}
+	return v.count
+}
+
+// motion moves the cursor or applies the pending operator to the text
+// between the cursor and the target of the motion.
+func (v *ViMode) motion(t *Terminal, cmd *SqlCommandBuffer, motion byte, symbol string) {
+	count := v.getCount()
+	if v.operator != 0 {
+		count *= v.operatorCount
+	}
+	v.count = 0
+
+	// "cw" changes the word without the following spaces like "ce"
+	if v.operator == 'c' && motion == 'w' && cmd.Position < cmd.Length() && !isSpaceSymbol(cmd.Text[cmd.Position]) {
+		motion = 'E'
+	}
+
+	target, inclusive, ok := viTarget(cmd, motion, symbol, count)
+	if !ok {
+		v.cancel()
+		return
+	}
+	if v.operator == 0 {
+		cmd.Position = target
+		v.clamp(cmd)
+		return
+	}
+
+	from, to := cmd.Position, target
+	if to < from {
+		from, to = to, from
+	}
+	if inclusive {
+		to = cmd.NextSymbol(to)
+	}
+	v.apply(t, cmd, from, to)
+}
+
+// apply applies the pending operator to the text between from and to.
+func (v *ViMode) apply(t *Terminal, cmd *SqlCommandBuffer, from uint64, to uint64) {
+	operator := v.operator
+	v.operator = 0
+	v.register = string(cmd.Text[from:to])
+
+	switch operator {
+	case 'y':
+		cmd.Position = from
+		v.keys = nil
+		return
+	case 'd':
+		cmd.BeginChange()
+		cmd.Remove(from, to)
+		cmd.Position = from
+		v.clamp(cmd)
+	case 'c':
+		cmd.BeginChange()
+		cmd.Remove(from, to)
+		cmd.Position = from
+		v.insert = true
+	}
+	v.change = true
+}
+
+// put inserts the register after or before the cursor.
+func (v *ViMode) put(cmd *SqlCommandBuffer, after bool) {
+	if v.register == "" {
+		return
+	}
+	cmd.BeginChange()
+	if after {
+		cmd.Position = cmd.NextSymbol(cmd.Position)
+	}
+	for i := 0; i < v.getCount(); i++ {
+		cmd.Insert([]byte(v.register))
+	}
+	cmd.Position = cmd.PreviousSymbol(cmd.Position)
+	v.count = 0
+	v.change = true
+}
+
+// clamp keeps the cursor on the last symbol of the line in the normal
+// mode.
+func (v *ViMode) clamp(cmd *SqlCommandBuffer) {
+	start, end := cmd.LineBounds(cmd.Position)
+	if cmd.Position == end && cmd.Position > start {
+		cmd.Position = cmd.PreviousSymbol(cmd.Position)
+	}
+}
+
+// finishChange keeps keys of the finished command if it has changed
+// the line and finishes its group of undo.
+func (v *ViMode) finishChange(cmd *SqlCommandBuffer) {
+	cmd.EndChange()
+	if v.change && !v.replaying {
+		v.lastChange = v.keys
+	}
+	v.keys = nil
+	v.change = false
+}
+
+// repeat replays the last change ("."). A count repeats it several
+// times.
+func (v *ViMode) repeat(t *Terminal, cmd *SqlCommandBuffer) {
+	keys := v.lastChange
+	count := v.getCount()
+	v.cancel()
+	if len(keys) == 0 {
+		return
+	}
+
+	v.replaying = true
+	for i := 0; i < count; i++ {
+		for _, c := range keys {
+			if v.insert && c != ESC {
+				replayInsert(cmd, c)
+				continue
+			}
+			v.keys = append(v.keys, c)
+			if v.insert {
+				v.handle(t, cmd, []byte{c})
+			} else {
+				v.normal(t, cmd, c)
+			}
+		}
+	}
+	v.replaying = false
+	v.keys = nil
+	v.change = false
+}
+
+// replayInsert repeats the key typed in the insert mode.
+func replayInsert(cmd *SqlCommandBuffer, c byte) {
+	switch {
+	case c == BACKSPACE || c == CTRL_H:
+		cmd.Delete()
+	case c >= ' ':
+		cmd.Insert([]byte{c})
+	}
+}
+
+// viTarget returns position where the motion moves the cursor and
+// whether the symbol at this position is included to the range of an
+// operator.
+func viTarget(cmd *SqlCommandBuffer, motion byte, symbol string, count int) (uint64, bool, bool) {
+	position := cmd.Position
+	start, end := cmd.LineBounds(cmd.Position)
+
+	switch motion {
+	case 'h', BACKSPACE:
+		for i := 0; i < count && position > start; i++ {
+			position = cmd.PreviousSymbol(position)
+		}
+	case 'l', ' ':
+		for i := 0; i < count && position < end; i++ {
+			position = cmd.NextSymbol(position)
+		}
+	case '0':
+		position = start
+	case '^':
+		position = start
+		for position < end && isSpaceSymbol(cmd.Text[position]) {
+			position++
+		}
+	case '$':
+		return end, false, true
+	case 'w':
+		for i := 0; i < count; i++ {
+			position = viNextWord(cmd, position)
+		}
+	case 'b':
+		for i := 0; i < count; i++ {
+			position = viPreviousWord(cmd, position)
+		}
+	case 'e', 'E':
+		for i := 0; i < count; i++ {
+			next := viWordEnd(cmd, position, motion == 'E' && i == 0)
+			if next == position {
+				break
+			}
+			position = next
+		}
+		return position, true, true
+	case 'f', 't':
+		for i := 0; i < count; i++ {
+			from := cmd.NextSymbol(position)
+			if motion == 't' && i > 0 && from < end {
+				from = cmd.NextSymbol(from)
+			}
+			found := false
+			for p := from; p < end; p = cmd.NextSymbol(p) {
+				if string(cmd.Text[p:cmd.NextSymbol(p)]) == symbol {
+					position, found = p, true
+					break
+				}
+			}
+			if !found {
+				return 0, false, false
+			}
+		}
+		if motion == 't' {
+			position = cmd.PreviousSymbol(position)
+		}
+		return position, true, true
+	case 'F', 'T':
+		for i := 0; i < count; i++ {
+			from := position
+			if motion == 'T' && i > 0 && from > start {
+				from = cmd.PreviousSymbol(from)
+			}
+			found := false
+			for p := from; p > start; {
+				p = cmd.PreviousSymbol(p)
+				if string(cmd.Text[p:cmd.NextSymbol(p)]) == symbol {
+					position, found = p, true
+					break
+				}
+			}
+			if !found {
+				return 0, false, false
+			}
+		}
+		if motion == 'T' {
+			position = cmd.NextSymbol(position)
+		}
+	}
+	return position, false, true
+}
+
+// viClass returns class of the symbol for word motions: 0 for spaces,
+// 1 for word symbols and 2 for other symbols.
+func viClass(c byte) int {
+	switch {
+	case isSpaceSymbol(c):
+		return 0
+	case isWordSymbol(c):
+		return 1
+	}
+	return 2
+}
+
+// viNextWord returns the start of the next word.
+func viNextWord(cmd *SqlCommandBuffer, position uint64) uint64 {
+	end := cmd.Length()
+	if position < end {
+		class := viClass(cmd.Text[position])
+		for position < end && class != 0 && viClass(cmd.Text[position]) == class {
+			position++
+		}
+	}
+	for position < end && viClass(cmd.Text[position]) == 0 {
+		position++
+	}
+	return position
+}
+
+// viPreviousWord returns the start of the previous word.
+func viPreviousWord(cmd *SqlCommandBuffer, position uint64) uint64 {
+	start := cmd.LineStart
+	for position > start && viClass(cmd.Text[position-1]) == 0 {
+		position--
+	}
+	if position > start {
+		class := viClass(cmd.Text[position-1])
+		for position > start && viClass(cmd.Text[position-1]) == class {
+			position--
+		}
+	}
+	return position
+}
+
+// viWordEnd returns the last symbol of the current or the next word.
+// If current is true, the end of the word under the cursor is returned.
+func viWordEnd(cmd *SqlCommandBuffer, position uint64, current bool) uint64 {
+	end := cmd.Length()
+	if cmd.NextSymbol(position) >= end {
+		return position
+	}
+	if !current {
+		position = cmd.NextSymbol(position)
+	}
+	for position+1 < end && viClass(cmd.Text[position]) == 0 {
+		position++
+	}
+	class := viClass(cmd.Text[position])
+	for position+1 < end && viClass(cmd.Text[position+1]) == class {
+		position++
+	}
+	return cmd.PreviousSymbol(position + 1)
+}
@@ -0,0 +1,176 @@
+package main
+
+import "testing"
+
+// viKeys passes the symbols of the keys to the vi mode, the keys which
+// are not handled by it are inserted like IoLoop does it.
+func viKeys(terminal *Terminal, cmd *SqlCommandBuffer, keys string) {
+	for _, symbol := range keys {
+		key := []byte(string(symbol))
+		if !terminal.Vi.handle(terminal, cmd, key) && key[0] >= ' ' {
+			insert(terminal, cmd, key)
+		}
+	}
+}
+
+func TestViOperators(t *testing.T) {
+	tests := []struct {
+		keys     string
+		text     string
+		position uint64
+	}{
+		{"abc def ghi\x1b0dw", "def ghi", 0},
+		{"abc def ghi\x1b0wdw", "abc ghi", 4},
+		{"abc def ghi\x1b0d$", "", 0},
+		{"abc def ghi\x1b0wd$", "abc ", 3},
+		{"abc def ghi\x1b0wD", "abc ", 3},
+		{"abc def ghi\x1b$db", "abc def i", 8},
+		{"abc def ghi\x1b0de", " def ghi", 0},
+		{"abc def ghi\x1b0wdd", "", 0},
+		{"abc def ghi\x1b0x", "bc def ghi", 0},
+		// cw changes the word without the following spaces
+		{"abc def ghi\x1b0cwxyz\x1b", "xyz def ghi", 2},
+		{"abc def ghi\x1b0wcwX\x1b", "abc X ghi", 4},
+		{"abc def ghi\x1b0c$X\x1b", "X", 0},
+		// counts of operators and motions
+		{"abc def ghi\x1b02dw", "ghi", 0},
+		{"abc def ghi\x1b0d2w", "ghi", 0},
+		{"abc def ghi\x1b02d2w", "", 0},
+		{"abc def ghi\x1b03x", " def ghi", 0},
+		{"abc def ghi\x1b02wx", "abc def hi", 8},
+		{"abc def ghi\x1b0c2wX\x1b", "X ghi", 0},
+		// yank, put and repeat
+		{"abc def ghi\x1b0yw$p", "abc def ghiabc ", 14},
+		{"abc def ghi\x1b0dw.", "ghi", 0},
+		{"abc def ghi\x1b0cwX\x1bw.", "X X ghi", 2},
+		// Escape cancels the pending command
+		{"abc def\x1b\x1b0x", "bc def", 0},
+		{"abc def\x1b0d\x1bx", "bc def", 0},
+		// undo
+		{"abc def ghi\x1b0dwdwu", "def ghi", 0},
+		{"abc def ghi\x1b0dwdwuu", "abc def ghi", 0},
+		{"abc def ghi\x1b0dwdw2u", "abc def ghi", 0},
+		{"abc def ghi\x1b0dwu", "abc def ghi", 0},
+		{"abc def ghi\x1b0cwxyz uvw\x1bu", "abc def ghi", 0},
+		{"abc def ghi\x1b0ywPu", "abc def ghi", 0},
+		{"abc\x1bix y\x1bu", "abc", 2},
+		{"abc def ghi\x1b0dwu\x12", "def ghi", 0},
+		{"abc def ghi\x1b0dwux\x12", "bc def ghi", 0},
+		// motions without operators
+		{"abc def ghi\x1b0w", "abc def ghi", 4},
+		{"abc def ghi\x1b0e", "abc def ghi", 2},
+		{"abc def ghi\x1b2b", "abc def ghi", 4},
+		{"abc def ghi\x1b0fe", "abc def ghi", 5},
+		{"abc def ghi\x1b0te", "abc def ghi", 4},
+		{"abc def ghi\x1bFa", "abc def ghi", 0},
+		{"abc def ghi\x1bTa", "abc def ghi", 1},
+		{"abc def ghi\x1b0$", "abc def ghi", 10},
+		{"abc def ghi\x1b0fz", "abc def ghi", 0},
+		// multi-byte symbols
+		{"абв где\x1b0x", "бв где", 0},
+		{"абв где\x1b0wx", "абв де", 7},
+		{"абв где\x1b0dw", "где", 0},
+		{"абв где\x1b", "абв где", 11},
+		{"абв где\x1b0ywP", "абв абв где", 6},
+		{"абв где\x1b0fд", "абв где", 9},
+		{"абв где\x1b0tд", "абв где", 7},
+		{"абв где\x1bFб", "абв где", 2},
+		{"абв где\x1bTб", "абв где", 4},
+		{"абв где\x1b0dfг", "де", 0},
+		{"абв где\x1b0fв", "абв где", 4},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, "")
+		terminal.Vi = NewViMode()
+		cmd := &SqlCommandBuffer{}
+		viKeys(terminal, cmd, test.keys)
+		restore()
+		if cmd.String() != test.text || cmd.Position != test.position {
+			t.Errorf("%q: %q at %d, expected %q at %d", test.keys, cmd.String(), cmd.Position, test.text, test.position)
+		}
+	}
+}
+
+func TestViMultiLine(t *testing.T) {
+	tests := []struct {
+		keys     string
+		text     string
+		position uint64
+	}{
+		// dd removes the current line with its new line
+		{"\x1bdd", "SELECT 1,", 0},
+		{"\x1bkdd", "2", 0},
+		{"\x1bkddu", "SELECT 1,\n2", 0},
+		// cc and yy work with the current line only
+		{"\x1bccx\x1b", "SELECT 1,\nx", 10},
+		{"\x1bkcc3\x1b", "3\n2", 0},
+		{"\x1bkyyjP", "SELECT 1,\nSELECT 1,2", 18},
+		// k and j move the cursor between the lines
+		{"\x1bk", "SELECT 1,\n2", 0},
+		{"\x1bkj", "SELECT 1,\n2", 10},
+		{"\x1bkk", "SELECT 1,\n2", 0},
+		{"\x1bj", "SELECT 1,\n2", 10},
+	}
+	for _, test := range tests {
+		terminal, restore := testTerminal(t, "")
+		terminal.Vi = NewViMode()
+		// the statement is pasted, so it is edited as one buffer
+		cmd := testBuffer("SELECT 1,\n2", 11)
+		viKeys(terminal, cmd, test.keys)
+		restore()
+		if cmd.String() != test.text || cmd.Position != test.position {
+			t.Errorf("%q: %q at %d, expected %q at %d", test.keys, cmd.String(), cmd.Position, test.text, test.position)
+		}
+	}
+}
+
+func TestViHistory(t *testing.T) {
+	terminal, restore := testTerminal(t, "")
+	defer restore()
+	terminal.Vi = NewViMode()
+	cmd := testBuffer("abc", 3)
+	// the cursor stays without history entries
+	viKeys(terminal, cmd, "\x1b0lk")
+	if cmd.String() != "abc" || cmd.Position != 1 {
+		t.Errorf("k without history: %q at %d", cmd.String(), cmd.Position)
+	}
+	terminal.History.Add("SELECT 1;")
+	viKeys(terminal, cmd, "$k")
+	if cmd.String() != "SELECT 1;" || cmd.Position != 0 {
+		t.Errorf("k failed: %q at %d", cmd.String(), cmd.Position)
+	}
+	viKeys(terminal, cmd, "$j")
+	if cmd.String() != "abc" || cmd.Position != 0 {
+		t.Errorf("j failed: %q at %d", cmd.String(), cmd.Position)
+	}
+	viKeys(terminal, cmd, "$j")
+	if cmd.String() != "abc" || cmd.Position != 2 {
+		t.Errorf("j after the newest entry: %q at %d", cmd.String(), cmd.Position)
+	}
+}
+
+func TestViModeIndicator(t *testing.T) {
+	terminal, restore := testTerminal(t, "")
+	defer restore()
+	terminal.Vi = NewViMode()
+	cmd := &SqlCommandBuffer{}
+
+	for _, test := range []struct {
+		keys      string
+		indicator string
+	}{
+		{"abc", "(ins) "},
+		{"\x1b", "(cmd) "},
+		{"0cw", "(ins) "},
+		{"\x1bA", "(ins) "},
+	} {
+		viKeys(terminal, cmd, test.keys)
+		if indicator := terminal.Vi.modeIndicator(); indicator != test.indicator {
+			t.Errorf("%q: mode %q, expected %q", test.keys, indicator, test.indicator)
+		}
+	}
+	terminal.Vi.Reset()
+	if !terminal.Vi.insert {
+		t.Errorf("Reset does not turn on the insert mode")
+	}
+}
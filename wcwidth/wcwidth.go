@@ -0,0 +1,177 @@
+// wcwidth package provides display width of text in a terminal and
+// splitting of text into grapheme clusters (user-perceived symbols).
+package wcwidth
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	zeroWidthJoiner     = 0x200d
+	variationSelector16 = 0xfe0f
+)
+
+// interval is a range of runes.
+type interval struct {
+	first rune
+	last  rune
+}
+
+// wide contains East Asian Wide (W) and Fullwidth (F) runes and
+// emoji which are shown in two columns.
+var wide = []interval{
+	{0x1100, 0x115f}, {0x231a, 0x231b}, {0x2329, 0x232a}, {0x23e9, 0x23ec},
+	{0x23f0, 0x23f0}, {0x23f3, 0x23f3}, {0x25fd, 0x25fe}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267f, 0x267f}, {0x2693, 0x2693}, {0x26a1, 0x26a1},
+	{0x26aa, 0x26ab}, {0x26bd, 0x26be}, {0x26c4, 0x26c5}, {0x26ce, 0x26ce},
+	{0x26d4, 0x26d4}, {0x26ea, 0x26ea}, {0x26f2, 0x26f3}, {0x26f5, 0x26f5},
+	{0x26fa, 0x26fa}, {0x26fd, 0x26fd}, {0x2705, 0x2705}, {0x270a, 0x270b},
+	{0x2728, 0x2728}, {0x274c, 0x274c}, {0x274e, 0x274e}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27b0, 0x27b0}, {0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c}, {0x2b50, 0x2b50}, {0x2b55, 0x2b55}, {0x2e80, 0x303e},
+	{0x3041, 0x33ff}, {0x3400, 0x4dbf}, {0x4e00, 0x9fff}, {0xa000, 0xa4cf},
+	{0xa960, 0xa97f}, {0xac00, 0xd7a3}, {0xf900, 0xfaff}, {0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f}, {0xff00, 0xff60}, {0xffe0, 0xffe6}, {0x16fe0, 0x16fe4},
+	{0x17000, 0x18aff}, {0x1b000, 0x1b2ff}, {0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e}, {0x1f191, 0x1f19a}, {0x1f200, 0x1f251}, {0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff}, {0x1f7e0, 0x1f7eb}, {0x1f90c, 0x1f9ff}, {0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd}, {0x30000, 0x3fffd},
+}
+
+// inTable returns true if the rune is in one of the intervals.
+func inTable(r rune, table []interval) bool {
+	first, last := 0, len(table)-1
+	for first <= last {
+		middle := (first + last) / 2
+		switch {
+		case r < table[middle].first:
+			last = middle - 1
+		case r > table[middle].last:
+			first = middle + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// RuneWidth returns number of columns which are used by the rune:
+// 0 for control symbols and combining marks, 2 for wide symbols and
+// 1 for others.
+func RuneWidth(r rune) int {
+	switch {
+	case r < 0x20 || r >= 0x7f && r < 0xa0:
+		return 0
+	case r < 0x300:
+		return 1
+	case isExtend(r) || unicode.Is(unicode.Cf, r) || r >= 0x1160 && r <= 0x11ff:
+		return 0
+	case inTable(r, wide):
+		return 2
+	}
+	return 1
+}
+
+// StringWidth returns number of columns which are used by the text.
+func StringWidth(s string) int {
+	width := 0
+	for len(s) > 0 {
+		n := NextGrapheme(s)
+		width += GraphemeWidth(s[:n])
+		s = s[n:]
+	}
+	return width
+}
+
+// GraphemeWidth returns number of columns which are used by the
+// grapheme cluster. It is width of its base symbol, emoji presentation
+// selector and regional indicator pairs (flags) make it wide.
+func GraphemeWidth(s string) int {
+	r, n := utf8.DecodeRuneInString(s)
+	width := RuneWidth(r)
+	if width == 1 && len(s) > n {
+		if isRegionalIndicator(r) {
+			return 2
+		}
+		for _, next := range s[n:] {
+			if next == variationSelector16 {
+				return 2
+			}
+		}
+	}
+	return width
+}
+
+// NextGrapheme returns length in bytes of the first grapheme cluster
+// of the text.
+func NextGrapheme(s string) int {
+	if len(s) == 0 {
+		return 0
+	}
+	if s[0] < utf8.RuneSelf && (len(s) == 1 || s[1] < utf8.RuneSelf) {
+		if s[0] == '\r' && len(s) > 1 && s[1] == '\n' {
+			return 2
+		}
+		return 1
+	}
+
+	previous, n := utf8.DecodeRuneInString(s)
+	if isControl(previous) {
+		return n
+	}
+	regionalIndicators := 0
+	if isRegionalIndicator(previous) {
+		regionalIndicators = 1
+	}
+
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		switch {
+		case isExtend(r) || r == zeroWidthJoiner:
+		case previous == zeroWidthJoiner && isPictographic(r):
+		case isRegionalIndicator(r) && regionalIndicators == 1:
+			regionalIndicators++
+		default:
+			return n
+		}
+		previous = r
+		n += size
+	}
+	return n
+}
+
+// PreviousGrapheme returns length in bytes of the last grapheme
+// cluster of the text.
+func PreviousGrapheme(s string) int {
+	last := 0
+	for i := 0; i < len(s); {
+		last = i
+		i += NextGrapheme(s[i:])
+	}
+	return len(s) - last
+}
+
+// isControl returns true for control symbols which are never combined
+// with other symbols.
+func isControl(r rune) bool {
+	return r < 0x20 || r >= 0x7f && r < 0xa0
+}
+
+// isExtend returns true for symbols which extend the previous one:
+// combining marks, variation selectors, emoji modifiers and tags.
+func isExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r >= 0x1f3fb && r <= 0x1f3ff || r >= 0xe0020 && r <= 0xe007f
+}
+
+// isRegionalIndicator returns true for symbols used in flags.
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// isPictographic returns true for emoji which may be joined by the
+// zero width joiner.
+func isPictographic(r rune) bool {
+	return r >= 0x2600 && r <= 0x27bf || r >= 0x2300 && r <= 0x23ff || r >= 0x1f000 && r <= 0x1faff
+}
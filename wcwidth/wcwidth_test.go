@@ -0,0 +1,76 @@
+// wcwidth package provides display width of text in a terminal and
+// splitting of text into grapheme clusters (user-perceived symbols).
+package wcwidth
+
+import "testing"
+
+func TestRuneWidth(t *testing.T) {
+	for r, expected := range map[rune]int{
+		'a':     1,
+		'\t':    0,
+		'ж':     1,
+		'中':     2,
+		'ｱ':     1,
+		'Ａ':     2,
+		0x0301:  0,
+		0x200d:  0,
+		0xfe0f:  0,
+		0x1f600: 2,
+		0x2764:  1,
+	} {
+		if RuneWidth(r) != expected {
+			t.Error("RuneWidth failed", string(r), RuneWidth(r))
+		}
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	for s, expected := range map[string]int{
+		"SELECT":                     6,
+		"привет":                     6,
+		"日本語":                        6,
+		"e\u0301":                    1,
+		"\u2764\ufe0f":               2,
+		"\U0001f468\u200d\U0001f469": 2,
+		"\U0001f1fa\U0001f1e6":       2,
+		"a\U0001f44d\U0001f3fdb":     4,
+	} {
+		if StringWidth(s) != expected {
+			t.Error("StringWidth failed", s, StringWidth(s))
+		}
+	}
+}
+
+func TestGraphemes(t *testing.T) {
+	s := "ae\u0301\U0001f468\u200d\U0001f469\u200d\U0001f467\U0001f1fa\U0001f1e6\U0001f1e9\r\n中"
+	expected := []string{
+		"a",
+		"e\u0301",
+		"\U0001f468\u200d\U0001f469\u200d\U0001f467",
+		"\U0001f1fa\U0001f1e6",
+		"\U0001f1e9",
+		"\r\n",
+		"中",
+	}
+
+	rest := s
+	for _, grapheme := range expected {
+		n := NextGrapheme(rest)
+		if rest[:n] != grapheme {
+			t.Errorf("NextGrapheme failed %q", rest[:n])
+		}
+		rest = rest[n:]
+	}
+	if NextGrapheme(rest) != 0 {
+		t.Error("NextGrapheme failed (empty string)")
+	}
+
+	rest = s
+	for i := len(expected) - 1; i >= 0; i-- {
+		n := PreviousGrapheme(rest)
+		if rest[len(rest)-n:] != expected[i] {
+			t.Errorf("PreviousGrapheme failed %q", rest[len(rest)-n:])
+		}
+		rest = rest[:len(rest)-n]
+	}
+}